@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blog-app-backend/config"
+	"blog-app-backend/logging"
+	"blog-app-backend/metrics"
+	"blog-app-backend/monitor"
+	"blog-app-backend/pagination"
+	"blog-app-backend/search"
+	"blog-app-backend/services"
+	"blog-app-backend/sitemap"
+	"blog-app-backend/theme"
+	"blog-app-backend/tracing"
+)
+
+// App owns every long-lived dependency of the backend. Handlers and
+// middleware receive what they need from it instead of reaching for
+// package-level globals, so several instances (e.g. one per test, each
+// with its own database) can run side by side.
+type App struct {
+	Config     *config.Config
+	DB         *gorm.DB
+	Replicas   *config.ReplicaSet // nil without read replicas
+	Metrics    *metrics.Metrics
+	Memory     *monitor.MemorySampler
+	Search     *search.Index
+	SearchSync *search.Syncer
+	Cursors    *pagination.Signer
+	Sitemaps   *sitemap.Cache
+	Theme      *theme.Theme // nil unless the backend serves pages
+	Logger     *slog.Logger
+	Moderator  services.Moderator
+	Mailer     services.Mailer
+	Keys       *services.KeyRing
+	Sessions   *services.SessionService
+	Audit      *services.AuditLog
+	OIDC       map[string]*services.OIDCProvider
+
+	ready             atomic.Bool
+	stopReplicaChecks func()
+	stopTracing       func(context.Context) error
+}
+
+// Option replaces one of the dependencies New would otherwise build,
+// typically with a fake in tests.
+type Option func(*App)
+
+// WithDB uses an existing database connection instead of opening one
+func WithDB(db *gorm.DB) Option {
+	return func(a *App) { a.DB = db }
+}
+
+// WithModerator replaces the DeepSeek content filter
+func WithModerator(m services.Moderator) Option {
+	return func(a *App) { a.Moderator = m }
+}
+
+// WithMailer replaces the configured mailer
+func WithMailer(m services.Mailer) Option {
+	return func(a *App) { a.Mailer = m }
+}
+
+// WithLogger replaces the logger built from the log configuration
+func WithLogger(l *slog.Logger) Option {
+	return func(a *App) { a.Logger = l }
+}
+
+// WithKeys uses an existing JWT key ring instead of loading one from disk
+func WithKeys(k *services.KeyRing) Option {
+	return func(a *App) { a.Keys = k }
+}
+
+// New builds the application from the configuration
+func New(cfg *config.Config, opts ...Option) (*App, error) {
+	a := &App{Config: cfg}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.Logger == nil {
+		a.Logger = logging.New(cfg.Log, os.Stderr)
+	}
+
+	// spans go to the configured exporter from here on
+	stopTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+	a.stopTracing = stopTracing
+
+	if a.DB == nil {
+		db, err := config.OpenDB(cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		a.DB = db
+		a.Logger.Info("database connected", "driver", cfg.Database.Driver)
+
+		// reads go to healthy replicas, if there are any
+		replicas, err := config.OpenReplicas(db, cfg.Database, a.Logger)
+		if err != nil {
+			return nil, err
+		}
+		if replicas != nil {
+			a.Replicas = replicas
+			a.stopReplicaChecks = replicas.Start(cfg.Database.ReplicaCheckInterval)
+			a.Logger.Info("routing reads to replicas", "replicas", len(cfg.Database.Replicas))
+		}
+	}
+
+	// a span for every query run with a request's context
+	if err := a.DB.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+
+	// full-text search, kept in step with the posts table; started by the caller
+	a.Search = search.NewIndex(cfg.Search.TitleBoost, cfg.Search.SnippetLength)
+	a.SearchSync = search.NewSyncer(a.DB, a.Search, cfg.Search.RebuildInterval, a.Logger)
+	if err := a.DB.Use(a.SearchSync); err != nil {
+		return nil, err
+	}
+
+	// sitemaps are generated on demand and dropped whenever a post changes
+	a.Sitemaps = sitemap.NewCache(cfg.Pages.SitemapCacheTTL)
+	if err := a.DB.Use(a.Sitemaps); err != nil {
+		return nil, err
+	}
+
+	// list cursors must survive restarts and work on every instance, which
+	// takes a configured secret
+	if cfg.Pagination.CursorSecret == "" {
+		a.Logger.Warn("pagination.cursor_secret is not set, list cursors will not outlive this process")
+	}
+	a.Cursors = pagination.NewSigner(cfg.Pagination.CursorSecret)
+
+	// a theme that does not parse is a configuration error
+	if cfg.Pages.Enabled {
+		t, err := theme.Load(cfg.Pages.ThemesDir, cfg.Pages.Theme)
+		if err != nil {
+			return nil, err
+		}
+		a.Theme = t
+	}
+
+	// refuse to start without JWT signing keys
+	if a.Keys == nil {
+		keys, err := services.NewKeyRing(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		a.Keys = keys
+	}
+
+	// Prometheus metrics for the database pools and moderation calls
+	a.Metrics = metrics.New()
+	if sqlDB, err := a.DB.DB(); err == nil {
+		a.Metrics.RegisterDB("primary", sqlDB)
+	}
+	if a.Replicas != nil {
+		a.Metrics.RegisterReplicas(a.Replicas)
+	}
+
+	// let the block and mutex profiles record something
+	runtime.SetBlockProfileRate(cfg.Diagnostics.BlockProfileRate)
+	runtime.SetMutexProfileFraction(cfg.Diagnostics.MutexProfileFraction)
+
+	// memory history and leak detection; started by the caller
+	a.Memory = monitor.NewMemorySampler(cfg.Memory, a.Logger)
+	a.Metrics.RegisterMemoryAlerts(a.Memory)
+
+	if a.Moderator == nil {
+		a.Moderator = services.NewContentFilterService(cfg.Moderation)
+	}
+	a.Moderator = a.Metrics.InstrumentModerator(a.Moderator)
+	if a.Mailer == nil {
+		a.Mailer = services.NewMailer(cfg.Mail, a.Logger)
+	}
+
+	a.Sessions = services.NewSessionService(a.DB, a.Keys, a.Mailer, a.Logger)
+	a.Audit = services.NewAuditLog(a.DB, a.Logger)
+	a.OIDC = services.NewOIDCProviders(cfg.OIDC)
+
+	a.ready.Store(true)
+	return a, nil
+}
+
+// Ready reports whether the app should receive traffic; it turns false
+// once shutdown has begun
+func (a *App) Ready() bool {
+	return a.ready.Load()
+}
+
+func (a *App) SetReady(ready bool) {
+	a.ready.Store(ready)
+}
+
+// PingDB checks that the database answers
+func (a *App) PingDB(ctx context.Context) error {
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close stops the replica health checks, releases the database connections
+// and flushes the remaining spans
+func (a *App) Close() error {
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.stopTracing(ctx); err != nil {
+			a.Logger.Error("flushing traces", "error", err)
+		}
+	}()
+
+	if a.Replicas != nil {
+		a.stopReplicaChecks()
+		if err := a.Replicas.Close(); err != nil {
+			a.Logger.Error("closing replicas", "error", err)
+		}
+	}
+
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,856 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the backend.
+//
+// Values are resolved in this order, later sources winning:
+//  1. the defaults from Defaults()
+//  2. an optional YAML or TOML file (-config flag or CONFIG_FILE)
+//  3. a .env file in the working directory
+//  4. environment variables (see the env tags)
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	JWT         JWTConfig         `yaml:"jwt" toml:"jwt"`
+	Moderation  ModerationConfig  `yaml:"moderation" toml:"moderation"`
+	Mail        MailConfig        `yaml:"mail" toml:"mail"`
+	OIDC        OIDCConfig        `yaml:"oidc" toml:"oidc"`
+	Accounts    AccountsConfig    `yaml:"accounts" toml:"accounts"`
+	Memory      MemoryConfig      `yaml:"memory" toml:"memory"`
+	Diagnostics DiagnosticsConfig `yaml:"diagnostics" toml:"diagnostics"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Search      SearchConfig      `yaml:"search" toml:"search"`
+	Pagination  PaginationConfig  `yaml:"pagination" toml:"pagination"`
+	Feed        FeedConfig        `yaml:"feed" toml:"feed"`
+	Public      PublicConfig      `yaml:"public" toml:"public"`
+	Pages       PagesConfig       `yaml:"pages" toml:"pages"`
+	Robots      RobotsConfig      `yaml:"robots" toml:"robots"`
+}
+
+type ServerConfig struct {
+	Port        int      `yaml:"port" toml:"port" env:"PORT"`
+	CORSOrigins []string `yaml:"cors_origins" toml:"cors_origins" env:"CORS_ORIGINS"`
+	// FrontendURL is where users are sent back to after SSO and in emails
+	FrontendURL string `yaml:"frontend_url" toml:"frontend_url" env:"FRONTEND_URL"`
+	// DrainDelay is how long /readyz reports "not ready" before the server
+	// stops accepting connections, so load balancers can take it out first
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// ProxyHeader is the header a reverse proxy sets to the client's address,
+	// e.g. X-Real-IP. It is only believed on requests from TrustedProxies;
+	// without it the client is whoever opens the connection. Rate limits,
+	// the diagnostics allow-list, sessions and the audit log all use it.
+	ProxyHeader string `yaml:"proxy_header" toml:"proxy_header" env:"PROXY_HEADER"`
+	// TrustedProxies are the addresses or CIDR ranges of those proxies
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type DatabaseConfig struct {
+	// Driver is one of mysql, postgres or sqlite
+	Driver string `yaml:"driver" toml:"driver" env:"DB_DRIVER"`
+	Host   string `yaml:"host" toml:"host" env:"DB_HOST"`
+	// Port 0 means the driver's default (3306 for MySQL, 5432 for PostgreSQL)
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	// Path is the SQLite database file, or ":memory:" for a throwaway database
+	Path string `yaml:"path" toml:"path" env:"DB_PATH"`
+	// SSLMode is passed to PostgreSQL as sslmode
+	SSLMode string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+
+	// Connection pool, applied to the primary and to every replica
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	// Replicas are host:port addresses of read replicas, reached with the
+	// same user, password and database name as the primary
+	Replicas []string `yaml:"replicas" toml:"replicas" env:"DB_REPLICAS"`
+	// ReplicaCheckInterval is how often replicas are pinged; reads go to the
+	// primary while no replica is healthy
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" toml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+}
+
+type JWTConfig struct {
+	KeysDir   string `yaml:"keys_dir" toml:"keys_dir" env:"JWT_KEYS_DIR"`
+	ActiveKID string `yaml:"active_kid" toml:"active_kid" env:"JWT_ACTIVE_KID"`
+	// RetiredKeys has a "<kid>=<RFC 3339 time>" entry for every key that
+	// no longer signs, saying when it stopped; its tokens are accepted for
+	// KeyOverlap after that
+	RetiredKeys []string      `yaml:"retired_keys" toml:"retired_keys" env:"JWT_RETIRED_KEYS"`
+	KeyOverlap  time.Duration `yaml:"key_overlap" toml:"key_overlap" env:"JWT_KEY_OVERLAP"`
+}
+
+// Retirements parses RetiredKeys into the time each key stopped signing
+func (c JWTConfig) Retirements() (map[string]time.Time, error) {
+	retired := make(map[string]time.Time, len(c.RetiredKeys))
+	for _, entry := range c.RetiredKeys {
+		kid, raw, ok := strings.Cut(entry, "=")
+		at, err := time.Parse(time.RFC3339, raw)
+		if !ok || kid == "" || err != nil {
+			return nil, fmt.Errorf("%q is not <kid>=<RFC 3339 time>", entry)
+		}
+		if _, dup := retired[kid]; dup {
+			return nil, fmt.Errorf("key %q is retired twice", kid)
+		}
+		retired[kid] = at
+	}
+	return retired, nil
+}
+
+type ModerationConfig struct {
+	APIKey  string        `yaml:"api_key" toml:"api_key" env:"DEEPSEEK_API_KEY" secret:"true"`
+	BaseURL string        `yaml:"base_url" toml:"base_url" env:"DEEPSEEK_BASE_URL"`
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"DEEPSEEK_TIMEOUT"`
+	// ReadinessCheck makes /readyz also require the moderation API to answer
+	ReadinessCheck bool `yaml:"readiness_check" toml:"readiness_check" env:"DEEPSEEK_READINESS_CHECK"`
+}
+
+// MailConfig configures outgoing email. Without an SMTP host, emails are
+// only written to the log.
+type MailConfig struct {
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" toml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `yaml:"from" toml:"from" env:"SMTP_FROM"`
+}
+
+// OIDCConfig lists the single sign-on providers. In the environment they
+// are named by OIDC_PROVIDERS=corp,... and configured with OIDC_CORP_ISSUER,
+// OIDC_CORP_CLIENT_ID, OIDC_CORP_CLIENT_SECRET, OIDC_CORP_REDIRECT_URL and
+// OIDC_CORP_SCOPES.
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `yaml:"providers" toml:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name" toml:"name"`
+	Issuer       string   `yaml:"issuer" toml:"issuer"`
+	ClientID     string   `yaml:"client_id" toml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret" secret:"true"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url"`
+	Scopes       []string `yaml:"scopes" toml:"scopes"`
+}
+
+type AccountsConfig struct {
+	// DeletionGrace is how long a deleted account can still be restored
+	DeletionGrace time.Duration `yaml:"deletion_grace" toml:"deletion_grace" env:"ACCOUNT_DELETION_GRACE"`
+}
+
+type DiagnosticsConfig struct {
+	// Addr, if set, serves the diagnostics and /metrics on their own
+	// listener (e.g. 127.0.0.1:6060) instead of under /api/admin/debug and
+	// on the main one
+	Addr string `yaml:"addr" toml:"addr" env:"DIAGNOSTICS_ADDR"`
+	// AllowedIPs are the addresses or CIDR ranges that may use them, and
+	// scrape /metrics without logging in. Behind
+	// a reverse proxy, set server.proxy_header and server.trusted_proxies:
+	// otherwise every request comes from the proxy, and allowing 127.0.0.1
+	// lets everyone in.
+	AllowedIPs []string `yaml:"allowed_ips" toml:"allowed_ips" env:"DIAGNOSTICS_ALLOWED_IPS"`
+	// MaxProfileDuration caps the seconds of CPU profiles and execution traces
+	MaxProfileDuration time.Duration `yaml:"max_profile_duration" toml:"max_profile_duration" env:"DIAGNOSTICS_MAX_PROFILE_DURATION"`
+	// BlockProfileRate and MutexProfileFraction are passed to the runtime;
+	// 0 leaves the block and mutex profiles empty
+	BlockProfileRate     int `yaml:"block_profile_rate" toml:"block_profile_rate" env:"DIAGNOSTICS_BLOCK_PROFILE_RATE"`
+	MutexProfileFraction int `yaml:"mutex_profile_fraction" toml:"mutex_profile_fraction" env:"DIAGNOSTICS_MUTEX_PROFILE_FRACTION"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format is json, or text for reading logs in a terminal
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+type SearchConfig struct {
+	// TitleBoost is how many content matches a title match is worth
+	TitleBoost float64 `yaml:"title_boost" toml:"title_boost" env:"SEARCH_TITLE_BOOST"`
+	// SnippetLength is the approximate length of result snippets in bytes
+	SnippetLength int `yaml:"snippet_length" toml:"snippet_length" env:"SEARCH_SNIPPET_LENGTH"`
+	// RebuildInterval is how often the index is rebuilt from the database,
+	// on top of the updates made as posts change
+	RebuildInterval time.Duration `yaml:"rebuild_interval" toml:"rebuild_interval" env:"SEARCH_REBUILD_INTERVAL"`
+}
+
+type PaginationConfig struct {
+	// CursorSecret signs list cursors so clients cannot forge them. When
+	// empty a random one is used, and cursors stop working on restart and
+	// do not carry over between instances.
+	CursorSecret string `yaml:"cursor_secret" toml:"cursor_secret" env:"PAGINATION_CURSOR_SECRET" secret:"true"`
+	// CountCacheTTL is how long a counted total is reused as the estimate
+	// returned with cursor pages; 0 counts on every request
+	CountCacheTTL time.Duration `yaml:"count_cache_ttl" toml:"count_cache_ttl" env:"PAGINATION_COUNT_CACHE_TTL"`
+}
+
+// Feed contents
+const (
+	FeedContentFull    = "full"
+	FeedContentSummary = "summary"
+)
+
+// FeedConfig covers the RSS, Atom and JSON feeds. They link to the post
+// pages, so they are only served when pages are enabled.
+type FeedConfig struct {
+	Title       string `yaml:"title" toml:"title" env:"FEED_TITLE"`
+	Description string `yaml:"description" toml:"description" env:"FEED_DESCRIPTION"`
+	// Items is how many of the latest posts a feed lists
+	Items int `yaml:"items" toml:"items" env:"FEED_ITEMS"`
+	// Content is full or summary; readers can ask for the other with
+	// ?content=
+	Content string `yaml:"content" toml:"content" env:"FEED_CONTENT"`
+	// MaxAge is how long readers and proxies may cache a feed
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age" env:"FEED_MAX_AGE"`
+}
+
+// PublicConfig covers the unauthenticated read API and the feeds
+type PublicConfig struct {
+	// CacheMaxAge is how long browsers and proxies may cache a response
+	CacheMaxAge time.Duration `yaml:"cache_max_age" toml:"cache_max_age" env:"PUBLIC_CACHE_MAX_AGE"`
+	// RateLimit is how many requests one IP may make per RateWindow
+	RateLimit  int           `yaml:"rate_limit" toml:"rate_limit" env:"PUBLIC_RATE_LIMIT"`
+	RateWindow time.Duration `yaml:"rate_window" toml:"rate_window" env:"PUBLIC_RATE_WINDOW"`
+}
+
+// PagesConfig covers the server-rendered public pages. They share the
+// feed's title and description.
+type PagesConfig struct {
+	// Enabled serves the home, post, tag, author and archive pages from
+	// the backend, in place of the status message on /
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"PAGES_ENABLED"`
+	// ThemesDir holds one directory of templates per theme; the built-in
+	// "default" theme needs none
+	ThemesDir string `yaml:"themes_dir" toml:"themes_dir" env:"PAGES_THEMES_DIR"`
+	Theme     string `yaml:"theme" toml:"theme" env:"PAGES_THEME"`
+	// BaseURL is where the pages, feeds and sitemaps are reached, for
+	// canonical URLs and links shared elsewhere; required with Enabled
+	BaseURL  string `yaml:"base_url" toml:"base_url" env:"PAGES_BASE_URL"`
+	PageSize int    `yaml:"page_size" toml:"page_size" env:"PAGES_PAGE_SIZE"`
+	// SitemapCacheTTL is how long a generated sitemap is served at most.
+	// Edits through this instance drop it sooner; the TTL catches the
+	// ones made on other instances.
+	SitemapCacheTTL time.Duration `yaml:"sitemap_cache_ttl" toml:"sitemap_cache_ttl" env:"PAGES_SITEMAP_CACHE_TTL"`
+}
+
+// RobotsConfig is served as /robots.txt, along with the sitemap's URL
+// when pages are enabled
+type RobotsConfig struct {
+	// Disallow are the path prefixes crawlers are asked to stay out of;
+	// "/" keeps them out altogether, e.g. on a staging server
+	Disallow []string `yaml:"disallow" toml:"disallow" env:"ROBOTS_DISALLOW"`
+	// Extra is appended as is, e.g. rules for a single crawler
+	Extra string `yaml:"extra" toml:"extra" env:"ROBOTS_EXTRA"`
+}
+
+// Tracing exporters
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+type TracingConfig struct {
+	// Exporter is none, otlp (OTLP over HTTP), stdout, or file
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint is the OTLP collector's host:port
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	// Insecure sends OTLP over plain HTTP instead of HTTPS
+	Insecure bool `yaml:"insecure" toml:"insecure" env:"TRACING_OTLP_INSECURE"`
+	// File receives one JSON span per line with the file exporter
+	File string `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1.
+	// Requests that arrive with a sampled traceparent are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	// ServiceName identifies the backend in the tracing UI
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
+type MemoryConfig struct {
+	// SampleInterval is how often memory statistics are recorded
+	SampleInterval time.Duration `yaml:"sample_interval" toml:"sample_interval" env:"MEMORY_SAMPLE_INTERVAL"`
+	// HistorySize is how many samples are kept; older ones are overwritten
+	HistorySize int `yaml:"history_size" toml:"history_size" env:"MEMORY_HISTORY_SIZE"`
+	// TrendWindow is the span of recent samples used to detect growth
+	TrendWindow time.Duration `yaml:"trend_window" toml:"trend_window" env:"MEMORY_TREND_WINDOW"`
+	// HeapGrowthAlertMB raises an alert when the heap grows faster than this many MB per hour
+	HeapGrowthAlertMB int `yaml:"heap_growth_alert_mb" toml:"heap_growth_alert_mb" env:"MEMORY_HEAP_GROWTH_ALERT_MB"`
+	// GoroutineGrowthAlert raises an alert when goroutines grow faster than this many per hour
+	GoroutineGrowthAlert int `yaml:"goroutine_growth_alert" toml:"goroutine_growth_alert" env:"MEMORY_GOROUTINE_GROWTH_ALERT"`
+}
+
+// Defaults returns the configuration used when nothing is set
+func Defaults() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            4000,
+			CORSOrigins:     []string{"http://localhost:3000"},
+			FrontendURL:     "http://localhost:3000",
+			DrainDelay:      5 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:  DriverMySQL,
+			Host:    "localhost",
+			User:    "root",
+			Name:    "blog_post",
+			Path:    "blog.db",
+			SSLMode: "disable",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ReplicaCheckInterval: 10 * time.Second,
+		},
+		JWT: JWTConfig{
+			KeysDir:    "keys",
+			KeyOverlap: 24 * time.Hour,
+		},
+		Moderation: ModerationConfig{
+			BaseURL: "https://api.deepseek.com/v1/chat/completions",
+			Timeout: 30 * time.Second,
+		},
+		Mail: MailConfig{
+			SMTPPort: 587,
+		},
+		Accounts: AccountsConfig{
+			DeletionGrace: 30 * 24 * time.Hour,
+		},
+		Diagnostics: DiagnosticsConfig{
+			AllowedIPs:           []string{"127.0.0.1", "::1"},
+			MaxProfileDuration:   30 * time.Second,
+			BlockProfileRate:     1000000, // one blocking event per ms spent blocked
+			MutexProfileFraction: 100,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Search: SearchConfig{
+			TitleBoost:      2,
+			SnippetLength:   200,
+			RebuildInterval: time.Hour,
+		},
+		Pagination: PaginationConfig{
+			CountCacheTTL: time.Minute,
+		},
+		Feed: FeedConfig{
+			Title:   "Blog",
+			Items:   20,
+			Content: FeedContentFull,
+			MaxAge:  5 * time.Minute,
+		},
+		Public: PublicConfig{
+			CacheMaxAge: time.Minute,
+			RateLimit:   120,
+			RateWindow:  time.Minute,
+		},
+		Pages: PagesConfig{
+			Theme:           "default",
+			PageSize:        10,
+			SitemapCacheTTL: 10 * time.Minute,
+		},
+		Robots: RobotsConfig{
+			Disallow: []string{"/api/"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    TraceExporterNone,
+			Endpoint:    "localhost:4318",
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+			ServiceName: "blog-app-backend",
+		},
+		Memory: MemoryConfig{
+			SampleInterval:       10 * time.Second,
+			HistorySize:          8640, // a day at the default interval
+			TrendWindow:          30 * time.Minute,
+			HeapGrowthAlertMB:    100,
+			GoroutineGrowthAlert: 500,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the optional config file
+// at path, the .env file and the environment. It does not validate.
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load .env file (never overrides variables that are already set)
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .env: %v", err)
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return nil, err
+	}
+	if err := applyOIDCEnv(&cfg.OIDC); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		_, err = toml.Decode(string(data), cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides every field that has an env tag with the variable's value
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(key)
+		if key == "" || !ok || raw == "" {
+			continue
+		}
+		if err := setField(value, raw); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", key, raw, err)
+		}
+	}
+	return nil
+}
+
+func setField(value reflect.Value, raw string) error {
+	switch value.Interface().(type) {
+	case string:
+		value.SetString(raw)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case []string:
+		value.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// applyOIDCEnv replaces the providers when OIDC_PROVIDERS is set
+func applyOIDCEnv(cfg *OIDCConfig) error {
+	names, ok := os.LookupEnv("OIDC_PROVIDERS")
+	if !ok {
+		return nil
+	}
+
+	cfg.Providers = nil
+	for _, name := range splitList(names) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       splitList(os.Getenv(prefix + "SCOPES")),
+		}
+		cfg.Providers = append(cfg.Providers, provider)
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate checks the configuration and reports every problem at once
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port (PORT) must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if !isHTTPURL(origin) {
+			add("server.cors_origins (CORS_ORIGINS): %q is not an http(s) origin", origin)
+		}
+	}
+	if !isHTTPURL(c.Server.FrontendURL) {
+		add("server.frontend_url (FRONTEND_URL): %q is not an http(s) URL", c.Server.FrontendURL)
+	}
+	if c.Server.DrainDelay < 0 {
+		add("server.drain_delay (SHUTDOWN_DRAIN_DELAY) can't be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout (SHUTDOWN_TIMEOUT) must be positive")
+	}
+	for _, entry := range c.Server.TrustedProxies {
+		if _, err := ParseIPNet(entry); err != nil {
+			add("server.trusted_proxies (TRUSTED_PROXIES): %v", err)
+		}
+	}
+	if c.Server.ProxyHeader != "" && len(c.Server.TrustedProxies) == 0 {
+		add("server.proxy_header (PROXY_HEADER) needs server.trusted_proxies (TRUSTED_PROXIES), or any client could set it")
+	}
+	if c.Server.ProxyHeader == "" && len(c.Server.TrustedProxies) > 0 {
+		add("server.trusted_proxies (TRUSTED_PROXIES) needs server.proxy_header (PROXY_HEADER)")
+	}
+
+	switch c.Database.Driver {
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			add("database.path (DB_PATH) is required for sqlite")
+		}
+		if len(c.Database.Replicas) > 0 {
+			add("database.replicas (DB_REPLICAS) are not supported with sqlite")
+		}
+	case DriverMySQL, DriverPostgres:
+		if c.Database.Host == "" {
+			add("database.host (DB_HOST) is required")
+		}
+		if c.Database.Port < 0 || c.Database.Port > 65535 {
+			add("database.port (DB_PORT) must be between 1 and 65535, got %d", c.Database.Port)
+		}
+		if c.Database.User == "" {
+			add("database.user (DB_USER) is required")
+		}
+		if c.Database.Name == "" {
+			add("database.name (DB_NAME) is required")
+		}
+	default:
+		add("database.driver (DB_DRIVER) must be mysql, postgres or sqlite, got %q", c.Database.Driver)
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		add("database.max_open_conns and max_idle_conns (DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS) can't be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns (DB_MAX_IDLE_CONNS) can't exceed max_open_conns (%d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		add("database.conn_max_lifetime and conn_max_idle_time can't be negative")
+	}
+	if len(c.Database.Replicas) > 0 && c.Database.ReplicaCheckInterval <= 0 {
+		add("database.replica_check_interval (DB_REPLICA_CHECK_INTERVAL) must be positive")
+	}
+
+	if c.JWT.KeysDir == "" {
+		add("jwt.keys_dir (JWT_KEYS_DIR) is required")
+	} else if info, err := os.Stat(c.JWT.KeysDir); err != nil || !info.IsDir() {
+		add("jwt.keys_dir (JWT_KEYS_DIR): %s is not a directory", c.JWT.KeysDir)
+	}
+	if _, err := c.JWT.Retirements(); err != nil {
+		add("jwt.retired_keys (JWT_RETIRED_KEYS): %v", err)
+	}
+	if c.JWT.KeyOverlap < 0 {
+		add("jwt.key_overlap (JWT_KEY_OVERLAP) can't be negative")
+	}
+
+	if !isHTTPURL(c.Moderation.BaseURL) {
+		add("moderation.base_url (DEEPSEEK_BASE_URL): %q is not an http(s) URL", c.Moderation.BaseURL)
+	}
+	if c.Moderation.Timeout <= 0 {
+		add("moderation.timeout (DEEPSEEK_TIMEOUT) must be positive")
+	}
+
+	if c.Mail.SMTPHost != "" {
+		if c.Mail.SMTPPort < 1 || c.Mail.SMTPPort > 65535 {
+			add("mail.smtp_port (SMTP_PORT) must be between 1 and 65535, got %d", c.Mail.SMTPPort)
+		}
+		if c.Mail.From == "" {
+			add("mail.from (SMTP_FROM) is required when SMTP_HOST is set")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range c.OIDC.Providers {
+		if p.Name == "" {
+			add("oidc.providers[%d].name is required", i)
+			continue
+		}
+		if seen[p.Name] {
+			add("oidc provider %q is configured twice", p.Name)
+		}
+		seen[p.Name] = true
+		if !isHTTPURL(p.Issuer) {
+			add("oidc provider %q: issuer must be an http(s) URL", p.Name)
+		}
+		if p.ClientID == "" {
+			add("oidc provider %q: client_id is required", p.Name)
+		}
+		if !isHTTPURL(p.RedirectURL) {
+			add("oidc provider %q: redirect_url must be an http(s) URL", p.Name)
+		}
+	}
+
+	if c.Accounts.DeletionGrace < 0 {
+		add("accounts.deletion_grace (ACCOUNT_DELETION_GRACE) can't be negative")
+	}
+
+	if c.Diagnostics.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Diagnostics.Addr); err != nil {
+			add("diagnostics.addr (DIAGNOSTICS_ADDR): %q is not a host:port address", c.Diagnostics.Addr)
+		}
+	}
+	for _, entry := range c.Diagnostics.AllowedIPs {
+		if _, err := ParseIPNet(entry); err != nil {
+			add("diagnostics.allowed_ips (DIAGNOSTICS_ALLOWED_IPS): %v", err)
+		}
+	}
+	if c.Diagnostics.MaxProfileDuration <= 0 {
+		add("diagnostics.max_profile_duration (DIAGNOSTICS_MAX_PROFILE_DURATION) must be positive")
+	}
+	if c.Diagnostics.BlockProfileRate < 0 || c.Diagnostics.MutexProfileFraction < 0 {
+		add("diagnostics block_profile_rate and mutex_profile_fraction can't be negative")
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		add("log.format (LOG_FORMAT) must be json or text, got %q", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case TraceExporterNone, TraceExporterStdout:
+	case TraceExporterOTLP:
+		if c.Tracing.Endpoint == "" {
+			add("tracing.endpoint (TRACING_OTLP_ENDPOINT) is required with the otlp exporter")
+		}
+	case TraceExporterFile:
+		if c.Tracing.File == "" {
+			add("tracing.file (TRACING_FILE) is required with the file exporter")
+		}
+	default:
+		add("tracing.exporter (TRACING_EXPORTER) must be none, otlp, stdout or file, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio (TRACING_SAMPLE_RATIO) must be between 0 and 1")
+	}
+
+	if c.Search.TitleBoost <= 0 {
+		add("search.title_boost (SEARCH_TITLE_BOOST) must be positive")
+	}
+	if c.Search.SnippetLength < 40 {
+		add("search.snippet_length (SEARCH_SNIPPET_LENGTH) must be at least 40, got %d", c.Search.SnippetLength)
+	}
+	if c.Search.RebuildInterval <= 0 {
+		add("search.rebuild_interval (SEARCH_REBUILD_INTERVAL) must be positive")
+	}
+
+	if c.Pagination.CursorSecret != "" && len(c.Pagination.CursorSecret) < 32 {
+		add("pagination.cursor_secret (PAGINATION_CURSOR_SECRET) must be at least 32 bytes")
+	}
+	if c.Pagination.CountCacheTTL < 0 {
+		add("pagination.count_cache_ttl (PAGINATION_COUNT_CACHE_TTL) must not be negative")
+	}
+
+	if c.Feed.Title == "" {
+		add("feed.title (FEED_TITLE) is required")
+	}
+	if c.Feed.Items < 1 || c.Feed.Items > 100 {
+		add("feed.items (FEED_ITEMS) must be between 1 and 100, got %d", c.Feed.Items)
+	}
+	if c.Feed.Content != FeedContentFull && c.Feed.Content != FeedContentSummary {
+		add("feed.content (FEED_CONTENT) must be full or summary, got %q", c.Feed.Content)
+	}
+	if c.Feed.MaxAge < 0 {
+		add("feed.max_age (FEED_MAX_AGE) must not be negative")
+	}
+
+	if c.Public.CacheMaxAge < 0 {
+		add("public.cache_max_age (PUBLIC_CACHE_MAX_AGE) must not be negative")
+	}
+	if c.Public.RateLimit < 1 {
+		add("public.rate_limit (PUBLIC_RATE_LIMIT) must be at least 1, got %d", c.Public.RateLimit)
+	}
+	if c.Public.RateWindow <= 0 {
+		add("public.rate_window (PUBLIC_RATE_WINDOW) must be positive")
+	}
+
+	if c.Pages.Theme == "" {
+		add("pages.theme (PAGES_THEME) is required")
+	}
+	if c.Pages.Enabled && c.Pages.BaseURL == "" {
+		add("pages.base_url (PAGES_BASE_URL) is required when pages are enabled")
+	} else if c.Pages.BaseURL != "" && !isHTTPURL(c.Pages.BaseURL) {
+		add("pages.base_url (PAGES_BASE_URL): %q is not an http(s) URL", c.Pages.BaseURL)
+	}
+	if c.Pages.PageSize < 1 || c.Pages.PageSize > 100 {
+		add("pages.page_size (PAGES_PAGE_SIZE) must be between 1 and 100, got %d", c.Pages.PageSize)
+	}
+	if c.Pages.SitemapCacheTTL <= 0 {
+		add("pages.sitemap_cache_ttl (PAGES_SITEMAP_CACHE_TTL) must be positive")
+	}
+
+	for _, path := range c.Robots.Disallow {
+		if !strings.HasPrefix(path, "/") {
+			add("robots.disallow (ROBOTS_DISALLOW): %q must start with /", path)
+		}
+	}
+
+	if c.Memory.SampleInterval <= 0 {
+		add("memory.sample_interval (MEMORY_SAMPLE_INTERVAL) must be positive")
+	}
+	if c.Memory.HistorySize < 1 {
+		add("memory.history_size (MEMORY_HISTORY_SIZE) must be at least 1")
+	}
+	if c.Memory.TrendWindow < 2*c.Memory.SampleInterval {
+		add("memory.trend_window (MEMORY_TREND_WINDOW) must cover at least two samples")
+	}
+	if c.Memory.HeapGrowthAlertMB < 0 || c.Memory.GoroutineGrowthAlert < 0 {
+		add("memory growth alert thresholds can't be negative (0 disables them)")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// ParseIPNet parses an IP address or CIDR range; a single address becomes
+// a range containing only itself
+func ParseIPNet(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Dump writes the configuration as YAML with every secret redacted
+func (c *Config) Dump(w io.Writer) error {
+	redacted := *c
+	redacted.OIDC.Providers = append([]OIDCProviderConfig(nil), c.OIDC.Providers...)
+	redact(reflect.ValueOf(&redacted).Elem())
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redacted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			redact(value)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			for j := 0; j < value.Len(); j++ {
+				redact(value.Index(j))
+			}
+		case field.Tag.Get("secret") == "true" && value.String() != "":
+			value.SetString("********")
+		}
+	}
+}
+
+// MustLoad loads and validates the configuration, exiting on any error
+func MustLoad(path string) *Config {
+	cfg, err := Load(path)
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
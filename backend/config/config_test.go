@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePagesBaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		enabled bool
+		baseURL string
+		wantErr string
+	}{
+		{"pages off, no base URL", false, "", ""},
+		{"pages on, base URL", true, "https://blog.example.com", ""},
+		{"pages on, no base URL", true, "", "pages.base_url (PAGES_BASE_URL) is required"},
+		{"not an http URL", false, "blog.example.com", "is not an http(s) URL"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.JWT.KeysDir = t.TempDir()
+			cfg.Pages.Enabled = tc.enabled
+			cfg.Pages.BaseURL = tc.baseURL
+
+			err := cfg.Validate()
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("Validate = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTRetirements(t *testing.T) {
+	cfg := JWTConfig{RetiredKeys: []string{"2025-01=2025-07-01T00:00:00Z"}}
+	retired, err := cfg.Retirements()
+	if err != nil || retired["2025-01"].Format("2006-01-02") != "2025-07-01" {
+		t.Errorf("Retirements = %v, %v", retired, err)
+	}
+
+	for _, entry := range []string{"2025-01", "=2025-07-01T00:00:00Z", "2025-01=yesterday"} {
+		cfg := JWTConfig{RetiredKeys: []string{entry}}
+		if _, err := cfg.Retirements(); err == nil {
+			t.Errorf("%q was accepted", entry)
+		}
+	}
+}
@@ -1,48 +1,176 @@
 package config
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
-	"os"
+	"net/url"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// Supported values of DatabaseConfig.Driver
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
 
-func ConnectDB() {
-	// Load .env file
-	err := godotenv.Load()
+// OpenDB connects to the configured database
+func OpenDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := dialectorFor(cfg)
 	if err != nil {
-		log.Println("Warning: .env file not found, using default environment variables")
+		return nil, err
 	}
 
-	// Database configuration
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "3306")
-	dbUser := getEnv("DB_USER", "root")
-	dbPassword := getEnv("DB_PASSWORD", "")
-	dbName := getEnv("DB_NAME", "blog_post")
-
-	// Create DSN (Data Source Name)
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
-
 	// Connect to database
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return nil, err
+	}
+	configurePool(sqlDB, cfg)
+	return db, nil
+}
+
+// configurePool applies the pool limits to one connection pool
+func configurePool(sqlDB *sql.DB, cfg DatabaseConfig) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// every connection to ":memory:" would get its own empty database, and
+	// the database goes with its connection, which must never be recycled
+	if cfg.Driver == DriverSQLite && cfg.Path == ":memory:" {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+}
+
+func dialectorFor(cfg DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverMySQL, "":
+		port := cfg.Port
+		if port == 0 {
+			port = 3306
+		}
+		// Create DSN (Data Source Name)
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.User, cfg.Password, cfg.Host, port, cfg.Name)
+		return mysql.Open(dsn), nil
+
+	case DriverPostgres:
+		port := cfg.Port
+		if port == 0 {
+			port = 5432
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.User, cfg.Password),
+			Host:     fmt.Sprintf("%s:%d", cfg.Host, port),
+			Path:     "/" + cfg.Name,
+			RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+		}
+		return postgres.Open(dsn.String()), nil
+
+	case DriverSQLite:
+		// foreign keys are off by default in SQLite; the busy timeout makes
+		// concurrent requests wait for the write lock instead of failing
+		dsn := cfg.Path + "?_foreign_keys=on&_busy_timeout=5000"
+		if cfg.Path != ":memory:" {
+			dsn = "file:" + dsn + "&_journal_mode=WAL"
+		}
+		return sqlite.Open(dsn), nil
 	}
+	return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+}
+
+// dialectorForConn wraps an already open connection pool
+func dialectorForConn(driver string, conn *sql.DB) gorm.Dialector {
+	switch driver {
+	case DriverPostgres:
+		return postgres.New(postgres.Config{Conn: conn})
+	case DriverSQLite:
+		return &sqlite.Dialector{Conn: conn}
+	}
+	return mysql.New(mysql.Config{Conn: conn})
+}
+
+// likeEscaper escapes the LIKE wildcards in user input. "!" is used as the
+// escape character because backslash handling differs between databases.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// ContainsAny is a query scope matching rows where any of the columns
+// contains q, case-insensitively and with q taken literally. It behaves the
+// same on MySQL, PostgreSQL and SQLite.
+func ContainsAny(q string, columns ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(q)) + "%"
+
+		conditions := make([]string, len(columns))
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			conditions[i] = fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '!'", column)
+			args[i] = pattern
+		}
+		return db.Where(strings.Join(conditions, " OR "), args...)
+	}
+}
+
+// aggregateTimeFormats are the text forms timestamps come back in from
+// SQLite, and from MySQL without parseTime
+var aggregateTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// AggregateTime scans a timestamp computed in SQL, such as MAX(created_at).
+// Drivers return those as time.Time only when they know the column type,
+// which SQLite does not for an expression.
+type AggregateTime struct {
+	time.Time
+}
+
+func (t *AggregateTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a time", value)
+	}
+	return nil
+}
 
-	log.Println("Database connected successfully!")
+func (t AggregateTime) Value() (driver.Value, error) {
+	return t.Time, nil
 }
 
-// Helper function to get environment variables with default values
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func (t *AggregateTime) parse(s string) error {
+	for _, layout := range aggregateTimeFormats {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
 	}
-	return defaultValue
+	return fmt.Errorf("cannot parse %q as a time", s)
 }
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryDatabaseOutlivesPoolTimeouts(t *testing.T) {
+	cfg := Defaults().Database
+	cfg.Driver = DriverSQLite
+	cfg.Path = ":memory:"
+	cfg.ConnMaxIdleTime = time.Millisecond
+	cfg.ConnMaxLifetime = time.Millisecond
+
+	db, err := OpenDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	if err := db.Exec("CREATE TABLE notes (body TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	// the pool closes expired connections at most once a second
+	time.Sleep(1200 * time.Millisecond)
+
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM notes").Scan(&count).Error; err != nil {
+		t.Fatalf("the in-memory database was lost: %v", err)
+	}
+}
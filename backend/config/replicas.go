@@ -0,0 +1,230 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// replicaPingTimeout bounds one replica health check
+const replicaPingTimeout = 2 * time.Second
+
+// ReplicaSet routes reads to healthy read replicas. Writes, transactions and
+// queries marked with dbresolver.Write stay on the primary, and so do reads
+// while every replica is down.
+type ReplicaSet struct {
+	primary  *sql.DB
+	replicas []*replica
+	byPool   map[gorm.ConnPool]*replica
+	next     atomic.Uint64
+	log      *slog.Logger
+}
+
+type replica struct {
+	addr    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// ReplicaStats describes one replica's health and connection pool
+type ReplicaStats struct {
+	Addr    string    `json:"addr"`
+	Healthy bool      `json:"healthy"`
+	Pool    PoolStats `json:"pool"`
+}
+
+// PoolStats is sql.DBStats in JSON form
+type PoolStats struct {
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration_ns"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+// NewPoolStats converts the stats of one connection pool
+func NewPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
+
+// OpenReplicas connects to the configured read replicas and registers them
+// with db. It returns nil when no replicas are configured.
+func OpenReplicas(db *gorm.DB, cfg DatabaseConfig, logger *slog.Logger) (*ReplicaSet, error) {
+	if len(cfg.Replicas) == 0 {
+		return nil, nil
+	}
+
+	primary, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	set := &ReplicaSet{
+		primary: primary,
+		byPool:  map[gorm.ConnPool]*replica{},
+		log:     logger,
+	}
+
+	var dialectors []gorm.Dialector
+	for _, addr := range cfg.Replicas {
+		replicaCfg, err := replicaConfig(cfg, addr)
+		if err != nil {
+			set.Close()
+			return nil, err
+		}
+		replicaDB, err := OpenDB(replicaCfg)
+		if err != nil {
+			set.Close()
+			return nil, fmt.Errorf("replica %s: %v", addr, err)
+		}
+		conn, _ := replicaDB.DB()
+
+		r := &replica{addr: addr, db: conn}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+		set.byPool[conn] = r
+		dialectors = append(dialectors, dialectorForConn(cfg.Driver, conn))
+	}
+
+	// The primary is listed as a replica too, so the policy is always asked
+	// (dbresolver skips it when there is a single replica) and can fall back
+	// to it.
+	dialectors = append(dialectors, dialectorForConn(cfg.Driver, primary))
+
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   set,
+	}))
+	if err != nil {
+		set.Close()
+		return nil, err
+	}
+	return set, nil
+}
+
+// replicaConfig is the primary's configuration pointed at a replica address
+func replicaConfig(cfg DatabaseConfig, addr string) (DatabaseConfig, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// no port: use the driver's default
+		host, port = addr, "0"
+	}
+	cfg.Host = host
+	if cfg.Port, err = strconv.Atoi(port); err != nil {
+		return cfg, fmt.Errorf("replica %s: invalid port", addr)
+	}
+	cfg.Replicas = nil
+	return cfg, nil
+}
+
+// Resolve picks a healthy replica round-robin, or the primary if there is none.
+// It implements dbresolver.Policy.
+func (s *ReplicaSet) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]gorm.ConnPool, 0, len(pools))
+	for _, pool := range pools {
+		if r, ok := s.byPool[pool]; ok && r.healthy.Load() {
+			healthy = append(healthy, pool)
+		}
+	}
+	if len(healthy) == 0 {
+		return s.primary
+	}
+	return healthy[s.next.Add(1)%uint64(len(healthy))]
+}
+
+// Start pings every replica now and then every interval, until the returned
+// function is called
+func (s *ReplicaSet) Start(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for {
+			s.check()
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// check pings each replica and logs every change of health
+func (s *ReplicaSet) check() {
+	for _, r := range s.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+		err := r.db.PingContext(ctx)
+		cancel()
+
+		if healthy := err == nil; healthy != r.healthy.Swap(healthy) {
+			if healthy {
+				s.log.Info("replica is back, routing reads to it", "replica", r.addr)
+			} else {
+				s.log.Warn("replica is down, reads fail over", "replica", r.addr, "error", err)
+			}
+		}
+	}
+}
+
+// Stats reports the health and connection pool of every replica
+func (s *ReplicaSet) Stats() []ReplicaStats {
+	stats := make([]ReplicaStats, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		stats = append(stats, ReplicaStats{
+			Addr:    r.addr,
+			Healthy: r.healthy.Load(),
+			Pool:    NewPoolStats(r.db.Stats()),
+		})
+	}
+	return stats
+}
+
+// Each calls fn for every replica with its address, connection pool and
+// a function reporting its current health
+func (s *ReplicaSet) Each(fn func(addr string, db *sql.DB, healthy func() bool)) {
+	for _, r := range s.replicas {
+		fn(r.addr, r.db, r.healthy.Load)
+	}
+}
+
+// Close closes the replica connections; the primary is left open
+func (s *ReplicaSet) Close() error {
+	var firstErr error
+	for _, r := range s.replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
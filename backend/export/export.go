@@ -0,0 +1,296 @@
+// Package export renders the public site - every page, feed and sitemap -
+// to static files, for a mirror or a fallback that any plain file server
+// can serve. Links between pages are relative; the URLs that must be
+// absolute (canonical links, feeds, sitemaps) use the configured base URL.
+//
+// Exports are incremental: the manifest written next to the files records
+// each post's UpdatedAt, and the next export into the same directory only
+// renders the posts that changed since, along with the lists, feeds and
+// sitemaps, which it rewrites only when their content differs.
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/models"
+	"blog-app-backend/routes"
+)
+
+var feedFormats = []string{"rss", "atom", "json"}
+
+var errNotFound = errors.New("not found")
+
+// Exporter renders the site through the same handlers that serve it
+type Exporter struct {
+	db      *gorm.DB
+	server  *fiber.App
+	logger  *slog.Logger
+	out     string
+	base    string
+	origin  string
+	version string
+}
+
+// Stats counts the files of an export
+type Stats struct {
+	Files     int
+	Written   int
+	Unchanged int
+	Removed   int
+}
+
+// New prepares an export into the directory out. It needs the pages
+// enabled and their base URL set.
+func New(a *app.App, out string) (*Exporter, error) {
+	if a.Theme == nil {
+		return nil, errors.New("export needs pages.enabled")
+	}
+	base := strings.TrimRight(a.Config.Pages.BaseURL, "/")
+	if base == "" {
+		return nil, errors.New("export needs pages.base_url, for the links that must be absolute")
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("pages.base_url: %w", err)
+	}
+
+	// files rendered with another theme or other settings are all stale
+	settings, err := json.Marshal([]any{a.Config.Pages, a.Config.Feed, a.Config.Robots})
+	if err != nil {
+		return nil, err
+	}
+	version := sha256.Sum256(append([]byte(a.Theme.Version+"\x00"), settings...))
+
+	return &Exporter{
+		db:      a.DB,
+		server:  routes.NewStatic(a),
+		logger:  a.Logger,
+		out:     out,
+		base:    base,
+		origin:  u.Scheme + "://" + u.Host,
+		version: hex.EncodeToString(version[:8]),
+	}, nil
+}
+
+// Run exports the site; full renders every post, changed or not
+func (e *Exporter) Run(ctx context.Context, full bool) (Stats, error) {
+	var stats Stats
+
+	// 1) what the previous export left behind
+	prev, err := readManifest(e.out)
+	if err != nil {
+		return stats, fmt.Errorf("reading the previous manifest: %w", err)
+	}
+	if prev.Version != e.version {
+		full = true
+	}
+	next := &Manifest{
+		Version:     e.version,
+		BaseURL:     e.base,
+		GeneratedAt: time.Now().UTC(),
+		Files:       map[string]File{},
+	}
+
+	// 2) every post, tag and author page with its feeds; pagination and
+	// the sitemaps are found by following links
+	queue, updated, err := e.seeds(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	// 3) render, following the links of each page, and write what changed
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		target := queue[0]
+		queue = queue[1:]
+		u, err := url.Parse(target)
+		if err != nil {
+			continue
+		}
+		file, ok := fileFor(u)
+		if !ok || seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		stamp, isPost := updated[file]
+		if isPost && !full && e.unchanged(file, prev.Files[file], stamp) {
+			next.Files[file] = prev.Files[file]
+			stats.Unchanged++
+			continue
+		}
+
+		body, contentType, err := e.render(ctx, target)
+		if errors.Is(err, errNotFound) {
+			e.logger.Warn("skipping link to a missing page", "path", target)
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+		var links []string
+		switch {
+		case strings.HasPrefix(contentType, fiber.MIMETextHTML):
+			body, links = e.relativize(file, body)
+		case file == "sitemap.xml":
+			links = e.sitemapLinks(body)
+		}
+		queue = append(queue, links...)
+
+		sum := sha256.Sum256(body)
+		entry := File{ContentType: contentType, Size: int64(len(body)), SHA256: hex.EncodeToString(sum[:])}
+		if isPost {
+			entry.UpdatedAt = &stamp
+		}
+		next.Files[file] = entry
+		if old, ok := prev.Files[file]; ok && old.SHA256 == entry.SHA256 && e.onDisk(file, entry.Size) {
+			stats.Unchanged++
+			continue
+		}
+		if err := writeFile(e.path(file), body); err != nil {
+			return stats, err
+		}
+		stats.Written++
+	}
+
+	// 4) drop the files of posts, tags and authors that are gone; only
+	// files the previous export wrote are ever removed
+	for file := range prev.Files {
+		if _, ok := next.Files[file]; ok || !filepath.IsLocal(filepath.FromSlash(file)) {
+			continue
+		}
+		if err := os.Remove(e.path(file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stats, err
+		}
+		e.removeEmptyDirs(filepath.Dir(e.path(file)))
+		stats.Removed++
+	}
+
+	// 5) the manifest last, so an interrupted export is redone next time
+	if err := next.write(e.out); err != nil {
+		return stats, err
+	}
+	stats.Files = len(next.Files)
+	return stats, nil
+}
+
+// seeds lists the paths every export starts from, and the UpdatedAt of
+// each post by the file of its page
+func (e *Exporter) seeds(ctx context.Context) ([]string, map[string]time.Time, error) {
+	db := e.db.WithContext(ctx)
+	published := func() *gorm.DB {
+		return db.Model(&models.Post{}).Where("published = ?", true)
+	}
+
+	var posts []models.Post
+	if err := published().Select("id", "updated_at").Order("id").Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+	var authors []string
+	if err := published().Distinct("author").Order("author").Pluck("author", &authors).Error; err != nil {
+		return nil, nil, err
+	}
+	var tags []string
+	err := db.Table("tags").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.published = ? AND posts.deleted_at IS NULL", true).
+		Distinct("tags.name").Order("tags.name").
+		Pluck("tags.name", &tags).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queue := []string{"/archive", "/robots.txt", "/sitemap.xml"}
+	withFeeds := func(page string) {
+		queue = append(queue, page)
+		for _, format := range feedFormats {
+			queue = append(queue, strings.TrimSuffix(page, "/")+"/feed."+format)
+		}
+	}
+	withFeeds("/")
+	for _, tag := range tags {
+		withFeeds("/tags/" + url.PathEscape(tag))
+	}
+	for _, author := range authors {
+		withFeeds("/authors/" + url.PathEscape(author))
+	}
+
+	updated := make(map[string]time.Time, len(posts))
+	for _, post := range posts {
+		queue = append(queue, fmt.Sprintf("/posts/%d", post.ID))
+		updated[fmt.Sprintf("posts/%d/index.html", post.ID)] = post.UpdatedAt
+	}
+	return queue, updated, nil
+}
+
+// render fetches a path of the site from the handlers
+func (e *Exporter) render(ctx context.Context, target string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.origin+target, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := e.server.Test(req, -1)
+	if err != nil {
+		return nil, "", fmt.Errorf("rendering %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("rendering %s: %s", target, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("rendering %s: %w", target, err)
+	}
+	return body, resp.Header.Get(fiber.HeaderContentType), nil
+}
+
+// unchanged reports whether the page of a post was exported at its
+// current UpdatedAt and is still there
+func (e *Exporter) unchanged(file string, old File, updatedAt time.Time) bool {
+	return old.UpdatedAt != nil && old.UpdatedAt.Equal(updatedAt) && e.onDisk(file, old.Size)
+}
+
+func (e *Exporter) onDisk(file string, size int64) bool {
+	info, err := os.Stat(e.path(file))
+	return err == nil && info.Mode().IsRegular() && info.Size() == size
+}
+
+func (e *Exporter) path(file string) string {
+	return filepath.Join(e.out, filepath.FromSlash(file))
+}
+
+// removeEmptyDirs removes dir and its parents up to the output directory,
+// stopping at the first that is not empty
+func (e *Exporter) removeEmptyDirs(dir string) {
+	out := filepath.Clean(e.out)
+	for dir != out && strings.HasPrefix(dir, out+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
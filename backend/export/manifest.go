@@ -0,0 +1,87 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestName is the manifest's file name in the output directory
+const ManifestName = "manifest.json"
+
+// Manifest lists every file of an export. The next export reads it to
+// skip the posts that have not changed and to remove the files that are
+// no longer part of the site.
+type Manifest struct {
+	// Version identifies the theme and settings the files were rendered
+	// with; when it changes, every file is rendered again
+	Version     string          `json:"version"`
+	BaseURL     string          `json:"base_url"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Files       map[string]File `json:"files"`
+}
+
+// File is one exported file, by its slash-separated path in the output
+type File struct {
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	// UpdatedAt is the post's UpdatedAt, for the page of a post
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// readManifest reads the manifest of a previous export; a directory that
+// has none gives an empty manifest
+func readManifest(dir string) (*Manifest, error) {
+	m := &Manifest{Files: map[string]File{}}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = map[string]File{}
+	}
+	return m, nil
+}
+
+func (m *Manifest) write(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, ManifestName), append(data, '\n'))
+}
+
+// writeFile replaces name in one step, so a file server never serves a
+// half-written file
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"html"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// anchorHref finds the links of <a> elements; <link> and <meta> URLs
+	// (canonical, OpenGraph, feeds) stay absolute, as their readers expect
+	anchorHref = regexp.MustCompile(`(?i)(<a\s[^>]*?\bhref=")([^"]*)(")`)
+	sitemapLoc = regexp.MustCompile(`<loc>([^<]*)</loc>`)
+)
+
+// fileFor maps a path of the site, with its query, to the slash-separated
+// file it is exported as: pages become directories with an index.html,
+// ?page=N their page/N subdirectory, and feeds and sitemaps files of their
+// own name. It reports false for URLs no file can stand for.
+func fileFor(u *url.URL) (string, bool) {
+	page := 1
+	for key, values := range u.Query() {
+		if key != "page" || len(values) != 1 {
+			return "", false
+		}
+		n, err := strconv.Atoi(values[0])
+		if err != nil || n < 1 {
+			return "", false
+		}
+		page = n
+	}
+
+	var segments []string
+	for _, raw := range strings.Split(strings.Trim(u.EscapedPath(), "/"), "/") {
+		if raw == "" {
+			continue
+		}
+		// an escaped / in an author's name must not become a directory
+		name, err := url.PathUnescape(raw)
+		if err != nil || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+			return "", false
+		}
+		segments = append(segments, name)
+	}
+
+	if isFile(segments) {
+		if page != 1 {
+			return "", false
+		}
+		return path.Join(segments...), true
+	}
+	if page > 1 {
+		segments = append(segments, "page", strconv.Itoa(page))
+	}
+	return path.Join(append(segments, "index.html")...), true
+}
+
+// isFile reports whether the path names a file rather than a page
+func isFile(segments []string) bool {
+	switch len(segments) {
+	case 0:
+		return false
+	case 1:
+		name := segments[0]
+		return name == "robots.txt" || name == "sitemap.xml" || strings.HasPrefix(name, "feed.")
+	case 2:
+		return segments[0] == "sitemaps"
+	case 3:
+		return (segments[0] == "tags" || segments[0] == "authors") && strings.HasPrefix(segments[2], "feed.")
+	}
+	return false
+}
+
+// relativeLink is the link from the file from to the file to, both
+// slash-separated paths in the output
+func relativeLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	link := strings.Join(segments, "/")
+	// a colon in the first segment would read as a URL scheme
+	if strings.Contains(segments[0], ":") {
+		link = "./" + link
+	}
+	return link
+}
+
+// relativize makes the links of the page in file that point into the site
+// relative to it, so the export can be served from anywhere. It returns
+// the rewritten page and the site paths it links to.
+func (e *Exporter) relativize(file string, body []byte) ([]byte, []string) {
+	var links []string
+	out := anchorHref.ReplaceAllFunc(body, func(match []byte) []byte {
+		parts := anchorHref.FindSubmatch(match)
+		target, ok := e.sitePath(html.UnescapeString(string(parts[2])))
+		if !ok {
+			return match
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return match
+		}
+		to, ok := fileFor(u)
+		if !ok {
+			return match
+		}
+		links = append(links, target)
+
+		link := relativeLink(file, to)
+		if u.Fragment != "" {
+			link += "#" + u.EscapedFragment()
+		}
+		return []byte(string(parts[1]) + html.EscapeString(link) + string(parts[3]))
+	})
+	return out, links
+}
+
+// sitemapLinks are the site paths of the sitemaps a sitemap index lists
+func (e *Exporter) sitemapLinks(body []byte) []string {
+	var links []string
+	for _, match := range sitemapLoc.FindAllSubmatch(body, -1) {
+		if target, ok := e.sitePath(html.UnescapeString(string(match[1]))); ok {
+			links = append(links, target)
+		}
+	}
+	return links
+}
+
+// sitePath turns a URL under the base URL into a path of the site, such
+// as /tags/go?page=2
+func (e *Exporter) sitePath(link string) (string, bool) {
+	if link == e.base {
+		return "/", true
+	}
+	rest, ok := strings.CutPrefix(link, e.base)
+	if !ok || !strings.HasPrefix(rest, "/") {
+		return "", false
+	}
+	return rest, true
+}
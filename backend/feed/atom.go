@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// AtomContentType is served for Atom
+const AtomContentType = "application/atom+xml; charset=utf-8"
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+type atomDoc struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    atomText       `xml:"summary"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Atom renders f as Atom 1.0 (RFC 4287)
+func Atom(f Feed) ([]byte, error) {
+	updated := f.Updated
+	if updated.IsZero() {
+		// required even for an empty feed
+		updated = time.Unix(0, 0)
+	}
+	doc := atomDoc{
+		NS:       atomNS,
+		ID:       f.FeedURL,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.HomeURL, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Link:      atomLink{Href: item.URL, Rel: "alternate", Type: "text/html"},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+			Author:    atomPerson{Name: item.Author},
+			Summary:   atomText{Type: "text", Value: item.Summary},
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if item.ContentHTML != "" {
+			entry.Content = &atomText{Type: "html", Value: item.ContentHTML}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
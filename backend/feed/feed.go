@@ -0,0 +1,68 @@
+// Package feed renders lists of posts as RSS 2.0, Atom 1.0 and JSON Feed
+// 1.1 documents.
+package feed
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Feed is what the three formats have in common
+type Feed struct {
+	Title       string
+	Description string
+	// HomeURL is the site the feed belongs to, FeedURL the feed itself
+	HomeURL string
+	FeedURL string
+	// Updated is when any of the items last changed
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is one post. ContentHTML is left empty for summary-only feeds.
+type Item struct {
+	// ID never changes once published; it is the post's URL
+	ID          string
+	URL         string
+	Title       string
+	Author      string
+	Summary     string
+	ContentHTML string
+	Published   time.Time
+	Updated     time.Time
+	Tags        []string
+}
+
+// TextToHTML turns a plain-text post into HTML: blank lines separate
+// paragraphs and other line breaks are kept
+func TextToHTML(text string) string {
+	var paras []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			paras = append(paras, "<p>"+strings.ReplaceAll(html.EscapeString(para), "\n", "<br>\n")+"</p>")
+		}
+	}
+	return strings.Join(paras, "\n")
+}
+
+// Summarize shortens text to about maxLen bytes on a word boundary, with
+// whitespace collapsed
+func Summarize(text string, maxLen int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= maxLen {
+		return text
+	}
+	cut := strings.LastIndexFunc(text[:maxLen+1], unicode.IsSpace)
+	if cut < maxLen/2 {
+		// one long word, or Thai without spaces: cut between runes
+		cut = maxLen
+		for cut > 0 && !isRuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimRightFunc(text[:cut], unicode.IsPunct) + "…"
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
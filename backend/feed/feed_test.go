@@ -0,0 +1,328 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The documents are decoded into structs of their own, written from the
+// specs, so a field the renderers name wrongly shows up as missing.
+
+func testFeed() Feed {
+	published := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("ICT", 7*3600))
+	return Feed{
+		Title:       "Blog & notes",
+		Description: "Notes on <Go>",
+		HomeURL:     "https://blog.example.com/",
+		FeedURL:     "https://blog.example.com/feed.xml",
+		Updated:     published.Add(2 * time.Hour),
+		Items: []Item{
+			{
+				ID:          "https://blog.example.com/posts/2",
+				URL:         "https://blog.example.com/posts/2",
+				Title:       "Full post",
+				Author:      "ann",
+				Summary:     "First lines",
+				ContentHTML: TextToHTML("First lines\n\nA <b> tag ]]> and more"),
+				Published:   published,
+				Updated:     published.Add(2 * time.Hour),
+				Tags:        []string{"go", "web-dev"},
+			},
+			{
+				ID:        "https://blog.example.com/posts/1",
+				URL:       "https://blog.example.com/posts/1",
+				Title:     "Summary only",
+				Author:    "bob",
+				Summary:   "Just a summary",
+				Published: published.Add(-24 * time.Hour),
+				Updated:   published.Add(-24 * time.Hour),
+			},
+		},
+	}
+}
+
+// RSS 2.0: https://www.rssboard.org/rss-specification
+func TestRSS(t *testing.T) {
+	f := testFeed()
+	out, err := RSS(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			// <link> and <atom:link> share a local name, so both land here
+			Links []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+				Href    string `xml:"href,attr"`
+				Rel     string `xml:"rel,attr"`
+			} `xml:"link"`
+			Items []struct {
+				Title string `xml:"title"`
+				Link  string `xml:"link"`
+				GUID  struct {
+					Value       string `xml:",chardata"`
+					IsPermaLink string `xml:"isPermaLink,attr"`
+				} `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+				Description string   `xml:"description"`
+				Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("not well-formed XML: %v\n%s", err, out)
+	}
+
+	if doc.Version != "2.0" {
+		t.Errorf("version = %q, want 2.0", doc.Version)
+	}
+	ch := doc.Channel
+	var link, self string
+	for _, l := range ch.Links {
+		switch {
+		case l.XMLName.Space == "":
+			link = l.Value
+		case l.XMLName.Space == "http://www.w3.org/2005/Atom" && l.Rel == "self":
+			self = l.Href
+		}
+	}
+	if ch.Title != f.Title || link != f.HomeURL || ch.Description != f.Description {
+		t.Errorf("channel title, link, description = %q, %q, %q", ch.Title, link, ch.Description)
+	}
+	if _, err := time.Parse(time.RFC1123Z, ch.LastBuildDate); err != nil {
+		t.Errorf("lastBuildDate: %v", err)
+	}
+	if self != f.FeedURL {
+		t.Errorf("atom:link rel=self = %q, want the feed's own URL", self)
+	}
+	if len(ch.Items) != len(f.Items) {
+		t.Fatalf("%d items, want %d", len(ch.Items), len(f.Items))
+	}
+	for i, item := range ch.Items {
+		want := f.Items[i]
+		if item.Title == "" && item.Description == "" {
+			t.Errorf("item %d has neither title nor description", i)
+		}
+		if item.GUID.Value != want.ID || item.GUID.IsPermaLink != "true" {
+			t.Errorf("item %d guid = %+v", i, item.GUID)
+		}
+		pubDate, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil || !pubDate.Equal(want.Published) {
+			t.Errorf("item %d pubDate = %q (%v), want %v", i, item.PubDate, err, want.Published)
+		}
+		if item.Creator != want.Author || item.Link != want.URL || item.Description != want.Summary {
+			t.Errorf("item %d creator, link, description = %q, %q, %q", i, item.Creator, item.Link, item.Description)
+		}
+		if item.Content != want.ContentHTML {
+			t.Errorf("item %d content:encoded = %q, want %q", i, item.Content, want.ContentHTML)
+		}
+	}
+	if got := ch.Items[0].Categories; len(got) != 2 || got[0] != "go" {
+		t.Errorf("categories = %v", got)
+	}
+}
+
+func TestRSSWithoutDescription(t *testing.T) {
+	f := testFeed()
+	f.Description = ""
+	out, err := RSS(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "<description>"+xmlEscape(f.Title)+"</description>") {
+		t.Errorf("channel description is empty; it is required:\n%s", out)
+	}
+}
+
+// Atom 1.0: RFC 4287
+func TestAtom(t *testing.T) {
+	f := testFeed()
+	out, err := Atom(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Author  []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Links   []link `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Updated   string `xml:"updated"`
+			Published string `xml:"published"`
+			Link      link   `xml:"link"`
+			Author    []struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Summary struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"summary"`
+			Content *struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("not an Atom feed: %v\n%s", err, out)
+	}
+
+	if doc.ID != f.FeedURL || doc.Title != f.Title {
+		t.Errorf("feed id, title = %q, %q", doc.ID, doc.Title)
+	}
+	updated, err := time.Parse(time.RFC3339, doc.Updated)
+	if err != nil || !updated.Equal(f.Updated) {
+		t.Errorf("feed updated = %q (%v), want %v", doc.Updated, err, f.Updated)
+	}
+	hasSelf := false
+	for _, l := range doc.Links {
+		hasSelf = hasSelf || (l.Rel == "self" && l.Href == f.FeedURL)
+	}
+	if !hasSelf {
+		t.Errorf("no rel=self link in %+v", doc.Links)
+	}
+	if len(doc.Entries) != len(f.Items) {
+		t.Fatalf("%d entries, want %d", len(doc.Entries), len(f.Items))
+	}
+	for i, entry := range doc.Entries {
+		want := f.Items[i]
+		if entry.ID != want.ID || entry.Title != want.Title {
+			t.Errorf("entry %d id, title = %q, %q", i, entry.ID, entry.Title)
+		}
+		if _, err := time.Parse(time.RFC3339, entry.Updated); err != nil {
+			t.Errorf("entry %d updated: %v", i, err)
+		}
+		if _, err := time.Parse(time.RFC3339, entry.Published); err != nil {
+			t.Errorf("entry %d published: %v", i, err)
+		}
+		// without a feed author, every entry needs one
+		if len(doc.Author) == 0 && (len(entry.Author) == 0 || entry.Author[0].Name == "") {
+			t.Errorf("entry %d has no author, and neither has the feed", i)
+		}
+		if entry.Link.Rel != "alternate" || entry.Link.Href != want.URL {
+			t.Errorf("entry %d link = %+v", i, entry.Link)
+		}
+		// an entry without content needs a summary
+		if entry.Content == nil && entry.Summary.Value == "" {
+			t.Errorf("entry %d has neither content nor summary", i)
+		}
+	}
+	if c := doc.Entries[0].Content; c == nil || c.Type != "html" || c.Value != f.Items[0].ContentHTML {
+		t.Errorf("entry 0 content = %+v", c)
+	}
+	if doc.Entries[1].Content != nil {
+		t.Error("a summary-only entry has content")
+	}
+}
+
+func TestAtomEmpty(t *testing.T) {
+	f := testFeed()
+	f.Items, f.Updated = nil, time.Time{}
+	out, err := Atom(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Updated string `xml:"updated"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse(time.RFC3339, doc.Updated); err != nil {
+		t.Errorf("an empty feed still needs updated: %v", err)
+	}
+}
+
+// JSON Feed 1.1: https://www.jsonfeed.org/version/1.1/
+func TestJSON(t *testing.T) {
+	f := testFeed()
+	out, err := JSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("not JSON: %v\n%s", err, out)
+	}
+	if doc["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("version = %v", doc["version"])
+	}
+	if doc["title"] != f.Title || doc["feed_url"] != f.FeedURL || doc["home_page_url"] != f.HomeURL {
+		t.Errorf("title, feed_url, home_page_url = %v, %v, %v", doc["title"], doc["feed_url"], doc["home_page_url"])
+	}
+	items, ok := doc["items"].([]any)
+	if !ok || len(items) != len(f.Items) {
+		t.Fatalf("items = %v", doc["items"])
+	}
+	for i, raw := range items {
+		item := raw.(map[string]any)
+		if id, ok := item["id"].(string); !ok || id != f.Items[i].ID {
+			t.Errorf("item %d id = %v, want the string %q", i, item["id"], f.Items[i].ID)
+		}
+		if item["content_html"] == nil && item["content_text"] == nil {
+			t.Errorf("item %d has neither content_html nor content_text", i)
+		}
+		for _, key := range []string{"date_published", "date_modified"} {
+			if _, err := time.Parse(time.RFC3339, item[key].(string)); err != nil {
+				t.Errorf("item %d %s: %v", i, key, err)
+			}
+		}
+		authors, ok := item["authors"].([]any)
+		if !ok || len(authors) != 1 || authors[0].(map[string]any)["name"] != f.Items[i].Author {
+			t.Errorf("item %d authors = %v", i, item["authors"])
+		}
+	}
+	if items[0].(map[string]any)["content_html"] != f.Items[0].ContentHTML {
+		t.Errorf("content_html = %v", items[0].(map[string]any)["content_html"])
+	}
+}
+
+func TestJSONEmpty(t *testing.T) {
+	f := testFeed()
+	f.Items = nil
+	out, err := JSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"items": []`) {
+		t.Errorf("items must be an array, even empty:\n%s", out)
+	}
+}
+
+func TestTextToHTMLEscapes(t *testing.T) {
+	got := TextToHTML("a <script> & b\nline\n\n\nnext")
+	want := "<p>a &lt;script&gt; &amp; b<br>\nline</p>\n<p>next</p>"
+	if got != want {
+		t.Errorf("TextToHTML = %q, want %q", got, want)
+	}
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// JSONContentType is the media type JSON Feed 1.1 asks for
+const JSONContentType = "application/feed+json; charset=utf-8"
+
+type jsonDoc struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentHTML   string       `json:"content_html,omitempty"`
+	ContentText   string       `json:"content_text,omitempty"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	DateModified  string       `json:"date_modified,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSON renders f as JSON Feed 1.1 (https://www.jsonfeed.org/version/1.1/)
+func JSON(f Feed) ([]byte, error) {
+	doc := jsonDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       []jsonItem{},
+	}
+	for _, item := range f.Items {
+		ji := jsonItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentHTML:   item.ContentHTML,
+			Summary:       item.Summary,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+			Authors:       []jsonAuthor{{Name: item.Author}},
+			Tags:          item.Tags,
+		}
+		if ji.ContentHTML == "" {
+			// an item must have content_html or content_text
+			ji.ContentText = item.Summary
+		}
+		doc.Items = append(doc.Items, ji)
+	}
+	// content_html reads better unescaped, and is safe in a JSON string
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// RSSContentType is served for RSS; text/xml would make browsers guess the
+// charset
+const RSSContentType = "application/rss+xml; charset=utf-8"
+
+type rssDoc struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	AtomNS       string     `xml:"xmlns:atom,attr"`
+	ContentNS    string     `xml:"xmlns:content,attr"`
+	DublinCoreNS string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title   string  `xml:"title"`
+	Link    string  `xml:"link"`
+	GUID    rssGUID `xml:"guid"`
+	PubDate string  `xml:"pubDate"`
+	// RSS wants an email address in <author>, so the name goes in dc:creator
+	Creator     string   `xml:"dc:creator"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// RSS renders f as RSS 2.0
+func RSS(f Feed) ([]byte, error) {
+	description := f.Description
+	if description == "" {
+		// required, and must not be empty
+		description = f.Title
+	}
+	doc := rssDoc{
+		Version:      "2.0",
+		AtomNS:       atomNS,
+		ContentNS:    "http://purl.org/rss/1.0/modules/content/",
+		DublinCoreNS: "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.HomeURL,
+			Description: description,
+			Self:        atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, item := range f.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{Value: item.ID, IsPermaLink: item.ID == item.URL},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Creator:     item.Author,
+			Categories:  item.Tags,
+			Description: item.Summary,
+		}
+		if item.ContentHTML != "" {
+			ri.Content = &cdata{Value: item.ContentHTML}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+type DeleteAccountRequest struct {
+	// Confirm must be the account's username, to avoid accidental deletion
+	Confirm    string `json:"confirm" validate:"required"`
+	Posts      string `json:"posts" validate:"required,oneof=delete anonymize reassign"`
+	ReassignTo string `json:"reassign_to" validate:"required_if=Posts reassign"`
+}
+
+var accountValidator = validator.New()
+
+// AccountHandler serves the self-service account endpoints
+type AccountHandler struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewAccountHandler(a *app.App) *AccountHandler {
+	return &AccountHandler{cfg: a.Config, db: a.DB}
+}
+
+// ExportMyData → GET /users/me/export
+// Returns a ZIP with everything we store about the user, as JSON and Markdown
+func (h *AccountHandler) ExportMyData(c *fiber.Ctx) error {
+	db := h.db.WithContext(c.UserContext())
+
+	var user models.User
+	if err := db.First(&user, middleware.CurrentUserID(c)).Error; err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	// posts linked to the account
+	var posts []models.Post
+	if err := db.Scopes(models.PostsOf(user)).
+		Order("created_at").
+		Find(&posts).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	var comments []models.Comment
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&comments).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	var sessions []models.Session
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&sessions).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	var identities []models.UserIdentity
+	if err := db.Where("user_id = ?", user.ID).Find(&identities).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	archive, err := buildExportArchive(user, posts, comments, sessions, identities)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not build export"})
+	}
+
+	filename := fmt.Sprintf("export-%s-%s.zip", user.Username, time.Now().Format("20060102"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Status(http.StatusOK).Send(archive)
+}
+
+func buildExportArchive(user models.User, posts []models.Post, comments []models.Comment, sessions []models.Session, identities []models.UserIdentity) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"posts.json", posts},
+		{"comments.json", comments},
+		{"sessions.json", sessions},
+		{"identities.json", identities},
+	}
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeZipFile(zw, f.name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// human-readable copies
+	var profile strings.Builder
+	fmt.Fprintf(&profile, "# %s\n\n", user.Username)
+	fmt.Fprintf(&profile, "- Email: %s\n", user.Email)
+	fmt.Fprintf(&profile, "- Full name: %s\n", user.FullName)
+	fmt.Fprintf(&profile, "- Member since: %s\n", user.CreatedAt.Format(time.RFC1123))
+	fmt.Fprintf(&profile, "- Posts: %d\n", len(posts))
+	fmt.Fprintf(&profile, "- Comments: %d\n", len(comments))
+	fmt.Fprintf(&profile, "\n## Sessions\n\n")
+	for _, s := range sessions {
+		fmt.Fprintf(&profile, "- %s from %s (%s)\n", s.CreatedAt.Format(time.RFC1123), s.IP, s.UserAgent)
+	}
+	if err := writeZipFile(zw, "profile.md", []byte(profile.String())); err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		md := fmt.Sprintf("# %s\n\n_By %s on %s_\n\n%s\n",
+			post.Title, post.Author, post.CreatedAt.Format("2006-01-02"), post.Content)
+		if err := writeZipFile(zw, fmt.Sprintf("posts/%d.md", post.ID), []byte(md)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// DeleteMyAccount → DELETE /users/me
+// Schedules the account for deletion after a grace period
+func (h *AccountHandler) DeleteMyAccount(c *fiber.Ctx) error {
+	db := h.db.WithContext(c.UserContext())
+
+	var req DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+
+	if err := accountValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var user models.User
+	if err := db.First(&user, middleware.CurrentUserID(c)).Error; err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	if req.Confirm != user.Username {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "confirm must match your username"})
+	}
+
+	var reassignTo *uint
+	if req.Posts == services.PostsActionReassign {
+		var heir models.User
+		if err := db.Where("username = ?", req.ReassignTo).First(&heir).Error; err != nil || heir.ID == user.ID {
+			return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "reassign_to must be another existing user"})
+		}
+		reassignTo = &heir.ID
+	}
+
+	scheduledAt := time.Now().Add(h.cfg.Accounts.DeletionGrace)
+	if err := db.Model(&user).Updates(map[string]interface{}{
+		"deletion_scheduled_at": scheduledAt,
+		"deletion_posts_action": req.Posts,
+		"deletion_reassign_to":  reassignTo,
+	}).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message":      "account scheduled for deletion",
+		"scheduled_at": scheduledAt,
+	})
+}
+
+// CancelAccountDeletion → POST /users/me/deletion/cancel
+func (h *AccountHandler) CancelAccountDeletion(c *fiber.Ctx) error {
+	result := h.db.WithContext(c.UserContext()).Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", middleware.CurrentUserID(c)).
+		Updates(map[string]interface{}{
+			"deletion_scheduled_at": nil,
+			"deletion_posts_action": "",
+			"deletion_reassign_to":  nil,
+		})
+	if result.Error != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "no deletion scheduled"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "account deletion cancelled"})
+}
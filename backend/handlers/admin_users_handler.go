@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+// impersonationLifetime is kept short: support sessions should not linger
+const impersonationLifetime = time.Hour
+
+type ListUsersResponse struct {
+	Items    []models.User `json:"items"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+type SetUserActiveRequest struct {
+	IsActive *bool `json:"is_active" validate:"required"`
+}
+
+type SetUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500"`
+}
+
+// UserStats is what GET /admin/users/:id/stats returns. It has no count of
+// reports received: nothing in the app lets users report an account or a
+// post yet, so there is nothing to count.
+type UserStats struct {
+	UserID             uint       `json:"user_id"`
+	PostCount          int64      `json:"post_count"`
+	PublishedPostCount int64      `json:"published_post_count"`
+	ActiveSessions     int64      `json:"active_sessions"`
+	LastLoginAt        *time.Time `json:"last_login_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+var adminValidator = validator.New()
+
+// AdminHandler serves the user management endpoints under /admin
+type AdminHandler struct {
+	cfg      *config.Config
+	db       *gorm.DB
+	mailer   services.Mailer
+	sessions *services.SessionService
+	audit    *services.AuditLog
+}
+
+func NewAdminHandler(a *app.App) *AdminHandler {
+	return &AdminHandler{
+		cfg:      a.Config,
+		db:       a.DB,
+		mailer:   a.Mailer,
+		sessions: a.Sessions,
+		audit:    a.Audit,
+	}
+}
+
+// ListUsers → GET /admin/users
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size", "20"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	q := strings.TrimSpace(c.Query("q", ""))
+
+	db := h.db.WithContext(c.UserContext()).Model(&models.User{})
+
+	if q != "" {
+		db = db.Scopes(config.ContainsAny(q, "username", "email", "full_name"))
+	}
+	if role := c.Query("role"); role != "" {
+		db = db.Where("role = ?", role)
+	}
+	if active := c.Query("active"); active != "" {
+		db = db.Where("is_active = ?", active == "true")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	var users []models.User
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(ListUsersResponse{
+		Items:    users,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// SetUserActive → PATCH /admin/users/:id/active
+// Disabling an account also revokes all of its sessions
+func (h *AdminHandler) SetUserActive(c *fiber.Ctx) error {
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return nil
+	}
+
+	var req SetUserActiveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+	if err := adminValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user.ID == middleware.CurrentUserID(c) && !*req.IsActive {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "you can't disable your own account"})
+	}
+
+	if err := h.db.WithContext(c.UserContext()).Model(&user).Update("is_active", *req.IsActive).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if !*req.IsActive {
+		if err := h.sessions.RevokeAll(user.ID); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+	}
+
+	h.audit.Record(middleware.CurrentUserID(c), "user.set_active", &user.ID, c.IP(), map[string]interface{}{
+		"is_active": *req.IsActive,
+	})
+
+	return c.Status(http.StatusOK).JSON(user)
+}
+
+// SetUserRole → PATCH /admin/users/:id/role
+func (h *AdminHandler) SetUserRole(c *fiber.Ctx) error {
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return nil
+	}
+
+	var req SetUserRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+	if err := adminValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user.ID == middleware.CurrentUserID(c) && req.Role != models.RoleAdmin {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "you can't remove your own admin role"})
+	}
+
+	previous := user.Role
+	if err := h.db.WithContext(c.UserContext()).Model(&user).Update("role", req.Role).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	h.audit.Record(middleware.CurrentUserID(c), "user.set_role", &user.ID, c.IP(), map[string]interface{}{
+		"from": previous,
+		"to":   req.Role,
+	})
+
+	return c.Status(http.StatusOK).JSON(user)
+}
+
+// ForcePasswordReset → POST /admin/users/:id/password-reset
+// Logs the user out everywhere and emails a reset link; the user can't
+// log in again until the password has been changed.
+func (h *AdminHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.db.WithContext(c.UserContext()).Model(&user).Update("password_reset_required", true).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if err := h.sessions.RevokeAll(user.ID); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if err := h.sendPasswordReset(c.UserContext(), user); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not send reset email"})
+	}
+
+	h.audit.Record(middleware.CurrentUserID(c), "user.force_password_reset", &user.ID, c.IP(), nil)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "password reset email sent"})
+}
+
+// ImpersonateUser → POST /admin/users/:id/impersonate
+// Ends the admin's own session and replaces its auth cookie with a
+// short-lived session as the user; the admin logs in again afterwards.
+// The session remembers the admin, and every request made through it is
+// audited. Admins can't be impersonated, and an impersonation can't start
+// another one.
+func (h *AdminHandler) ImpersonateUser(c *fiber.Ctx) error {
+	if middleware.CurrentImpersonatorID(c) != 0 {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "you are already impersonating a user"})
+	}
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return nil
+	}
+	if user.Role == models.RoleAdmin {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "admins can't be impersonated"})
+	}
+
+	var req ImpersonateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+	if err := adminValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !user.IsActive {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "account is disabled"})
+	}
+
+	adminID := middleware.CurrentUserID(c)
+	session, token, err := h.sessions.Start(c.UserContext(), user, services.NewSession{
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		IP:             c.IP(),
+		Lifetime:       impersonationLifetime,
+		ImpersonatorID: &adminID,
+	})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not impersonate"})
+	}
+	// the cookie is about to be replaced: don't leave the admin's own
+	// session alive behind it
+	adminSessionID := middleware.CurrentSessionID(c)
+	if err := h.sessions.Revoke(adminSessionID); err != nil {
+		h.sessions.Revoke(session.ID)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not impersonate"})
+	}
+
+	h.audit.Record(adminID, "impersonation.start", &user.ID, c.IP(), map[string]interface{}{
+		"reason":           req.Reason,
+		"session_id":       session.ID,
+		"expires_at":       session.ExpiresAt,
+		"admin_session_id": adminSessionID,
+	})
+
+	setAuthCookie(c, token, session.ExpiresAt)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":    "impersonating " + user.Username,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+// GetUserStats → GET /admin/users/:id/stats
+func (h *AdminHandler) GetUserStats(c *fiber.Ctx) error {
+	db := h.db.WithContext(c.UserContext())
+
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return nil
+	}
+
+	stats := UserStats{UserID: user.ID, CreatedAt: user.CreatedAt}
+
+	posts := db.Model(&models.Post{}).Where("user_id = ?", user.ID)
+	if err := posts.Session(&gorm.Session{}).Count(&stats.PostCount).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if err := posts.Session(&gorm.Session{}).Where("published = ?", true).Count(&stats.PublishedPostCount).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	if err := db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", user.ID, time.Now()).
+		Count(&stats.ActiveSessions).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	// impersonation sessions are not the user's own logins
+	var last models.Session
+	err := db.Where("user_id = ? AND impersonator_id IS NULL", user.ID).Order("created_at DESC").First(&last).Error
+	if err == nil {
+		stats.LastLoginAt = &last.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(stats)
+}
+
+// ListAuditLogs → GET /admin/audit
+func (h *AdminHandler) ListAuditLogs(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size", "50"))
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	db := h.db.WithContext(c.UserContext()).Model(&models.AuditLog{})
+	if target := c.Query("target_user_id"); target != "" {
+		db = db.Where("target_user_id = ?", target)
+	}
+	if actor := c.Query("actor_id"); actor != "" {
+		db = db.Where("actor_id = ?", actor)
+	}
+	if action := c.Query("action"); action != "" {
+		db = db.Where("action = ?", action)
+	}
+
+	var entries []models.AuditLog
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&entries).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"items": entries, "page": page, "page_size": pageSize})
+}
+
+// findTargetUser loads the user named by the :id route parameter. When it
+// returns false the error response has already been written.
+func (h *AdminHandler) findTargetUser(c *fiber.Ctx) (models.User, bool) {
+	var user models.User
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+		return user, false
+	}
+
+	if err := h.db.WithContext(c.UserContext()).First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		} else {
+			c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+		return user, false
+	}
+
+	return user, true
+}
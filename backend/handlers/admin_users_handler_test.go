@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+type impersonationTest struct {
+	t      *testing.T
+	a      *app.App
+	server *fiber.App
+}
+
+func newImpersonationTest(t *testing.T) *impersonationTest {
+	a := newTestApp(t, nil)
+	server := fiber.New()
+	protected := server.Group("/api", middleware.JWTProtected(a.Sessions, a.Audit))
+	protected.Get("/me", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+	admin := protected.Group("/admin", middleware.RequireRole(models.RoleAdmin))
+	admin.Post("/users/:id/impersonate", NewAdminHandler(a).ImpersonateUser)
+	return &impersonationTest{t: t, a: a, server: server}
+}
+
+func (it *impersonationTest) user(name, role string) models.User {
+	user := models.User{Username: name, Email: name + "@example.com", Password: "x", Role: role, IsActive: true}
+	if err := it.a.DB.Create(&user).Error; err != nil {
+		it.t.Fatal(err)
+	}
+	return user
+}
+
+func (it *impersonationTest) login(user models.User, impersonator *uint) (models.Session, string) {
+	session, token, err := it.a.Sessions.Start(context.Background(), user, services.NewSession{ImpersonatorID: impersonator})
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	return session, token
+}
+
+func (it *impersonationTest) do(method, path, token, body string) *http.Response {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := it.server.Test(req, -1)
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	return resp
+}
+
+func (it *impersonationTest) impersonate(token string, target models.User) *http.Response {
+	return it.do(http.MethodPost, "/api/admin/users/"+strconv.Itoa(int(target.ID))+"/impersonate", token, `{"reason":"support ticket 42"}`)
+}
+
+func TestImpersonateUser(t *testing.T) {
+	it := newImpersonationTest(t)
+	admin := it.user("admin", models.RoleAdmin)
+	user := it.user("user", models.RoleUser)
+	adminSession, adminToken := it.login(admin, nil)
+
+	resp := it.impersonate(adminToken, user)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	var token string
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "auth_token" {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("no impersonation cookie")
+	}
+
+	// the admin's own session ended with the cookie it was replaced by
+	if err := it.a.DB.First(&adminSession, adminSession.ID).Error; err != nil || adminSession.RevokedAt == nil {
+		t.Errorf("the admin's session is still alive (%v)", err)
+	}
+	if resp := it.do(http.MethodGet, "/api/me", adminToken, ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("the admin's old token: status %d, want 401", resp.StatusCode)
+	}
+
+	// reads are audited too
+	if resp := it.do(http.MethodGet, "/api/me", token, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("the impersonation token: status %d", resp.StatusCode)
+	}
+	var entry models.AuditLog
+	err := it.a.DB.Where("action = ? AND actor_id = ? AND target_user_id = ?", "impersonation.request", admin.ID, user.ID).
+		First(&entry).Error
+	if err != nil || !strings.Contains(entry.Details, `"method":"GET"`) {
+		t.Errorf("no audit entry for a GET while impersonating: %+v, %v", entry, err)
+	}
+}
+
+func TestImpersonateRefusals(t *testing.T) {
+	it := newImpersonationTest(t)
+	admin := it.user("admin", models.RoleAdmin)
+	other := it.user("other", models.RoleAdmin)
+	user := it.user("user", models.RoleUser)
+
+	_, adminToken := it.login(admin, nil)
+	if resp := it.impersonate(adminToken, other); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("impersonating an admin: status %d, want 403", resp.StatusCode)
+	}
+
+	// a session opened on an admin's behalf, e.g. before admins were refused
+	_, nested := it.login(other, &admin.ID)
+	if resp := it.impersonate(nested, user); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("impersonating from an impersonation: status %d, want 403", resp.StatusCode)
+	}
+
+	var started int64
+	it.a.DB.Model(&models.Session{}).Where("user_id = ? AND impersonator_id IS NOT NULL", user.ID).Count(&started)
+	if started != 0 {
+		t.Errorf("%d impersonation sessions started", started)
+	}
+}
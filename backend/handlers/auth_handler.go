@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/metrics"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+// AuthHandler serves registration, login (password and single sign-on),
+// logout and password resets
+type AuthHandler struct {
+	cfg      *config.Config
+	db       *gorm.DB
+	mailer   services.Mailer
+	keys     *services.KeyRing
+	sessions *services.SessionService
+	oidc     map[string]*services.OIDCProvider
+	metrics  *metrics.Metrics
+}
+
+func NewAuthHandler(a *app.App) *AuthHandler {
+	return &AuthHandler{
+		cfg:      a.Config,
+		db:       a.DB,
+		mailer:   a.Mailer,
+		keys:     a.Keys,
+		sessions: a.Sessions,
+		oidc:     a.OIDC,
+		metrics:  a.Metrics,
+	}
+}
+
+// setAuthCookie sets the signed JWT as an httpOnly cookie
+func setAuthCookie(c *fiber.Ctx, token string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "auth_token",
+		Value:    token,
+		HTTPOnly: true,
+		Secure:   true, // Only send over HTTPS in production
+		SameSite: "Strict",
+		Expires:  expires,
+		Path:     "/",
+	})
+}
+
+// startSession records a new session for the user on this device and sets
+// its JWT cookie. Every way of logging in ends here.
+func (h *AuthHandler) startSession(c *fiber.Ctx, user models.User) error {
+	session, token, err := h.sessions.Start(c.UserContext(), user, services.NewSession{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	})
+	if err != nil {
+		return err
+	}
+
+	setAuthCookie(c, token, session.ExpiresAt)
+	return nil
+}
@@ -1,18 +1,13 @@
 package handlers
 
 import (
-	"log"
 	"net/http"
-	"os"
-	"runtime"
-	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/plugin/dbresolver"
 
-	"blog-app-backend/config"
 	"blog-app-backend/middleware"
 	"blog-app-backend/models"
 )
@@ -24,13 +19,8 @@ type LoginRequest struct {
 
 var loginValidator = validator.New()
 
-func Login(c *fiber.Ctx) error {
-	startTime := time.Now()
-	startStats := middleware.GetMemoryStats()
-
-	log.Printf("[LOGIN-START] Memory: %.2fMB, Goroutines: %d",
-		middleware.BytesToMB(startStats.Alloc), runtime.NumGoroutine())
-
+// Login → POST /auth/login
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	// 1) parse JSON into DTO
 	var req LoginRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -42,54 +32,44 @@ func Login(c *fiber.Ctx) error {
 		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// 3) find user by email
+	// 3) find user by email (on the primary, so a fresh account can log in)
 	var user models.User
-	if err := config.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	logger := middleware.Logger(c)
+	if err := h.db.WithContext(c.UserContext()).Clauses(dbresolver.Write).Where("email = ?", req.Email).First(&user).Error; err != nil {
+		h.metrics.Login("password", "invalid_credentials")
+		logger.Warn("login failed", "reason", "unknown_email", "email", req.Email)
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "invalid email or password"})
 	}
 
 	// 4) compare password hash
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.metrics.Login("password", "invalid_credentials")
+		logger.Warn("login failed", "reason", "wrong_password", "user_id", user.ID)
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "invalid email or password"})
 	}
 
-	// 5) generate JWT
-	secret := os.Getenv("JWT_SECRET")
-	claims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+	// 5) check the account may log in
+	if !user.IsActive {
+		h.metrics.Login("password", "account_disabled")
+		logger.Warn("login failed", "reason", "account_disabled", "user_id", user.ID)
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "account is disabled"})
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(secret))
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not login"})
+	if user.PasswordResetRequired {
+		h.metrics.Login("password", "reset_required")
+		logger.Warn("login failed", "reason", "reset_required", "user_id", user.ID)
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "password reset required, check your email"})
 	}
 
-	// 6) set httpOnly cookie
-	c.Cookie(&fiber.Cookie{
-		Name:     "auth_token",
-		Value:    signedToken,
-		HTTPOnly: true,
-		Secure:   true, // Only send over HTTPS in production
-		SameSite: "Strict",
-		Expires:  time.Now().Add(time.Hour * 24),
-		Path:     "/",
-	})
-
-	// 7) log final memory stats
-	endStats := middleware.GetMemoryStats()
-	duration := time.Since(startTime)
-	memoryDelta := int64(endStats.Alloc) - int64(startStats.Alloc)
-
-	log.Printf("[LOGIN-END] Duration: %v, Memory: %.2fMB (+%.2fMB), Goroutines: %d, User: %s",
-		duration,
-		middleware.BytesToMB(endStats.Alloc),
-		middleware.BytesToMB(uint64(memoryDelta)),
-		runtime.NumGoroutine(),
-		user.Email)
+	// 6) create the session and set the JWT cookie
+	if err := h.startSession(c, user); err != nil {
+		h.metrics.Login("password", "error")
+		logger.Error("login failed", "reason", "session_error", "user_id", user.ID, "error", err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not login"})
+	}
+	h.metrics.Login("password", "success")
+	logger.Info("login succeeded", "user_id", user.ID)
 
-	// 8) return response (without token)
+	// 7) return response (without token)
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"message": "login successful",
 		"user": fiber.Map{
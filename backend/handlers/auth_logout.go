@@ -7,7 +7,15 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func Logout(c *fiber.Ctx) error {
+// Logout → POST /auth/logout
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	// Revoke the session behind the cookie so the token can't be reused
+	if tokenStr := c.Cookies("auth_token"); tokenStr != "" {
+		if err := h.sessions.RevokeToken(tokenStr); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+	}
+
 	// Clear the auth cookie by setting it to expire in the past
 	c.Cookie(&fiber.Cookie{
 		Name:     "auth_token",
@@ -22,4 +30,4 @@ func Logout(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"message": "logout successful",
 	})
-}
\ No newline at end of file
+}
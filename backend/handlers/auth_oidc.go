@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+const oidcFlowCookie = "oidc_flow"
+
+// oidcErrors are the error codes of an authorization response (RFC 6749,
+// OpenID Connect Core) that the login page is sent as they are
+var oidcErrors = map[string]bool{
+	"access_denied":           true,
+	"login_required":          true,
+	"consent_required":        true,
+	"interaction_required":    true,
+	"temporarily_unavailable": true,
+}
+
+// oidcFlow is kept in a short-lived cookie between the redirect to the
+// identity provider and the callback
+type oidcFlow struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+}
+
+// OIDCLogin → GET /auth/oidc/:provider/login
+func (h *AuthHandler) OIDCLogin(c *fiber.Ctx) error {
+	provider, ok := h.oidc[c.Params("provider")]
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "unknown identity provider"})
+	}
+
+	flow := oidcFlow{
+		Provider: provider.Name(),
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: oauth2.GenerateVerifier(),
+		Next:     safeRedirectPath(c.Query("next", "/posts")),
+	}
+
+	authURL, err := provider.AuthCodeURL(c.UserContext(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		middleware.Logger(c).Error("oidc login failed", "provider", provider.Name(), "error", err)
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{"error": "identity provider unavailable"})
+	}
+
+	encoded, err := json.Marshal(flow)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not start login"})
+	}
+
+	// Lax, not Strict: the browser must send it on the redirect back from the provider
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    base64.RawURLEncoding.EncodeToString(encoded),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+		Expires:  time.Now().Add(10 * time.Minute),
+		Path:     "/api/auth/oidc",
+	})
+
+	return c.Redirect(authURL, http.StatusFound)
+}
+
+// OIDCCallback → GET /auth/oidc/:provider/callback
+func (h *AuthHandler) OIDCCallback(c *fiber.Ctx) error {
+	provider, ok := h.oidc[c.Params("provider")]
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "unknown identity provider"})
+	}
+
+	// 1) restore the flow started by OIDCLogin and clear its cookie
+	flow, err := readOIDCFlow(c)
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    "",
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+		Expires:  time.Now().Add(-time.Hour),
+		Path:     "/api/auth/oidc",
+	})
+	if err != nil || flow.Provider != provider.Name() || flow.State != c.Query("state") {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid login state"})
+	}
+
+	// the provider's error is passed on only if the login page knows it
+	if errCode := c.Query("error"); errCode != "" {
+		if !oidcErrors[errCode] {
+			errCode = "sso_failed"
+		}
+		h.metrics.Login("oidc", errCode)
+		return c.Redirect(frontendURL(h.cfg, "/login?error="+url.QueryEscape(errCode)), http.StatusFound)
+	}
+
+	// 2) exchange the code and verify the ID token
+	identity, err := provider.Exchange(c.UserContext(), c.Query("code"), flow.Nonce, flow.Verifier)
+	if err != nil {
+		h.metrics.Login("oidc", "sso_failed")
+		middleware.Logger(c).Warn("oidc callback failed", "provider", provider.Name(), "error", err)
+		return c.Redirect(frontendURL(h.cfg, "/login?error=sso_failed"), http.StatusFound)
+	}
+
+	// 3) find or create the linked user
+	user, err := h.linkOIDCUser(c.UserContext(), provider.Name(), identity)
+	if err != nil {
+		h.metrics.Login("oidc", "sso_failed")
+		middleware.Logger(c).Warn("oidc callback failed", "provider", provider.Name(), "error", err)
+		return c.Redirect(frontendURL(h.cfg, "/login?error=sso_failed"), http.StatusFound)
+	}
+	if !user.IsActive {
+		h.metrics.Login("oidc", "account_disabled")
+		return c.Redirect(frontendURL(h.cfg, "/login?error=account_disabled"), http.StatusFound)
+	}
+
+	// 4) same session cookie as a password login
+	if err := h.startSession(c, user); err != nil {
+		h.metrics.Login("oidc", "error")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not login"})
+	}
+	h.metrics.Login("oidc", "success")
+
+	return c.Redirect(frontendURL(h.cfg, flow.Next), http.StatusFound)
+}
+
+// linkOIDCUser returns the user already linked to the identity, or links the
+// user with the same verified email, or creates a new user.
+func (h *AuthHandler) linkOIDCUser(ctx context.Context, provider string, identity *services.OIDCIdentity) (models.User, error) {
+	var user models.User
+	db := h.db.WithContext(ctx)
+
+	var link models.UserIdentity
+	err := db.Where("provider = ? AND subject = ?", provider, identity.Subject).First(&link).Error
+	if err == nil {
+		return user, db.First(&user, link.UserID).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	// only a verified email may be used to link to an existing account
+	if identity.Email == "" || !identity.EmailVerified {
+		return user, fmt.Errorf("identity %s has no verified email", identity.Subject)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("email = ?", identity.Email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user, err = newOIDCUser(tx, identity)
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}).Error
+	})
+
+	return user, err
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// newOIDCUser creates an account for someone signing in for the first time.
+// It gets a random password, so it can only be used through single sign-on.
+func newOIDCUser(tx *gorm.DB, identity *services.OIDCIdentity) (models.User, error) {
+	base := identity.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	base = usernameUnsafe.ReplaceAllString(strings.ToLower(base), "")
+	if len(base) < 3 {
+		base = "user"
+	}
+	base = truncate(base, 40)
+
+	username := base
+	for i := 2; ; i++ {
+		var cnt int64
+		if err := tx.Model(&models.User{}).Where("username = ?", username).Count(&cnt).Error; err != nil {
+			return models.User{}, err
+		}
+		if cnt == 0 {
+			break
+		}
+		username = fmt.Sprintf("%s%d", base, i)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(randomToken()), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Username: username,
+		Email:    identity.Email,
+		Password: string(hash),
+		FullName: identity.Name,
+		Avatar:   identity.Picture,
+		IsActive: true,
+	}
+	return user, tx.Create(&user).Error
+}
+
+func readOIDCFlow(c *fiber.Ctx) (oidcFlow, error) {
+	var flow oidcFlow
+	raw, err := base64.RawURLEncoding.DecodeString(c.Cookies(oidcFlowCookie))
+	if err != nil {
+		return flow, err
+	}
+	err = json.Unmarshal(raw, &flow)
+	return flow, err
+}
+
+// safeRedirectPath only allows local paths, so the login can't be used as an open redirect
+func safeRedirectPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		return "/posts"
+	}
+	return next
+}
+
+func frontendURL(cfg *config.Config, path string) string {
+	return strings.TrimRight(cfg.Server.FrontendURL, "/") + path
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
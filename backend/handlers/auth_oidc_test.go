@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+
+	"blog-app-backend/config"
+	"blog-app-backend/models"
+)
+
+const mockClientID = "blog"
+
+// mockIssuer is a local OpenID Connect provider with discovery, JWKS and
+// token endpoints. Tests say which claims each authorization code gets.
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key, codes: map[string]jwt.MapClaims{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": "mock",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		claims, ok := m.codes[r.FormValue("code")]
+		delete(m.codes, r.FormValue("code"))
+		m.mu.Unlock()
+		if !ok || r.FormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]any{"error": "invalid_grant"})
+			return
+		}
+
+		claims["iss"] = m.URL
+		claims["aud"] = mockClientID
+		claims["iat"] = time.Now().Unix()
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "mock"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// issue makes code redeemable for an ID token with claims
+func (m *mockIssuer) issue(code string, claims jwt.MapClaims) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codes[code] = claims
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+type oidcTest struct {
+	t      *testing.T
+	issuer *mockIssuer
+	server *fiber.App
+	cfg    *config.Config
+	h      *AuthHandler
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	issuer := newMockIssuer(t)
+	a := newTestApp(t, func(cfg *config.Config) {
+		cfg.OIDC.Providers = []config.OIDCProviderConfig{{
+			Name:         "mock",
+			Issuer:       issuer.URL,
+			ClientID:     mockClientID,
+			ClientSecret: "secret",
+			RedirectURL:  "http://localhost:4000/api/auth/oidc/mock/callback",
+		}}
+	})
+
+	h := NewAuthHandler(a)
+	server := fiber.New()
+	server.Get("/api/auth/oidc/:provider/login", h.OIDCLogin)
+	server.Get("/api/auth/oidc/:provider/callback", h.OIDCCallback)
+	return &oidcTest{t: t, issuer: issuer, server: server, cfg: a.Config, h: h}
+}
+
+// login starts a flow and returns its cookie and the state and nonce the
+// provider was sent
+func (o *oidcTest) login() (*http.Cookie, string, string) {
+	o.t.Helper()
+	resp, err := o.server.Test(httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/login?next=/drafts", nil), -1)
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusFound {
+		o.t.Fatalf("login: status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != o.issuer.URL+"/authorize" {
+		o.t.Fatalf("login redirects to %s, want the provider's authorization endpoint", got)
+	}
+	if location.Query().Get("code_challenge_method") != "S256" {
+		o.t.Errorf("login does not use PKCE: %s", location)
+	}
+	cookie := findCookie(resp, oidcFlowCookie)
+	if cookie == nil {
+		o.t.Fatal("login sets no flow cookie")
+	}
+	return cookie, location.Query().Get("state"), location.Query().Get("nonce")
+}
+
+func (o *oidcTest) callback(cookie *http.Cookie, query url.Values) *http.Response {
+	o.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/callback?"+query.Encode(), nil)
+	req.AddCookie(cookie)
+	resp, err := o.server.Test(req, -1)
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	return resp
+}
+
+func findCookie(resp *http.Response, name string) *http.Cookie {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == name && cookie.Value != "" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestOIDCLoginCallbackCreatesUser(t *testing.T) {
+	o := newOIDCTest(t)
+	cookie, state, nonce := o.login()
+	o.issuer.issue("code-1", jwt.MapClaims{
+		"sub":                "alice-sub",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "Alice",
+		"nonce":              nonce,
+	})
+
+	resp := o.callback(cookie, url.Values{"code": {"code-1"}, "state": {state}})
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("callback: status %d", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("Location"), o.cfg.Server.FrontendURL+"/drafts"; got != want {
+		t.Errorf("callback redirects to %q, want %q", got, want)
+	}
+	if findCookie(resp, "auth_token") == nil {
+		t.Error("callback starts no session")
+	}
+
+	var user models.User
+	if err := o.h.db.Where("email = ?", "alice@example.com").First(&user).Error; err != nil {
+		t.Fatalf("no user created: %v", err)
+	}
+	if user.Username != "alice" {
+		t.Errorf("username = %q, want alice", user.Username)
+	}
+	var link models.UserIdentity
+	if err := o.h.db.Where("provider = ? AND subject = ?", "mock", "alice-sub").First(&link).Error; err != nil {
+		t.Fatalf("identity not linked: %v", err)
+	}
+	if link.UserID != user.ID {
+		t.Errorf("identity linked to user %d, want %d", link.UserID, user.ID)
+	}
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+	cookie, _, nonce := o.login()
+	o.issuer.issue("code-1", jwt.MapClaims{"sub": "alice-sub", "nonce": nonce})
+
+	resp := o.callback(cookie, url.Values{"code": {"code-1"}, "state": {"forged"}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("callback with another state: status %d, want 400", resp.StatusCode)
+	}
+	if findCookie(resp, "auth_token") != nil {
+		t.Error("callback with another state starts a session")
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+	cookie, state, _ := o.login()
+	o.issuer.issue("code-1", jwt.MapClaims{
+		"sub":            "alice-sub",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"nonce":          "replayed",
+	})
+
+	resp := o.callback(cookie, url.Values{"code": {"code-1"}, "state": {state}})
+	if got, want := resp.Header.Get("Location"), o.cfg.Server.FrontendURL+"/login?error=sso_failed"; got != want {
+		t.Errorf("callback redirects to %q, want %q", got, want)
+	}
+	if findCookie(resp, "auth_token") != nil {
+		t.Error("callback with another nonce starts a session")
+	}
+	var count int64
+	o.h.db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users created, want none", count)
+	}
+}
+
+func TestOIDCCallbackLinksVerifiedEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	existing := models.User{Username: "bob", Email: "bob@example.com", Password: "x", IsActive: true}
+	if err := o.h.db.Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// an unverified email is not enough to take over the account
+	cookie, state, nonce := o.login()
+	o.issuer.issue("code-1", jwt.MapClaims{"sub": "bob-sub", "email": "bob@example.com", "nonce": nonce})
+	resp := o.callback(cookie, url.Values{"code": {"code-1"}, "state": {state}})
+	if findCookie(resp, "auth_token") != nil {
+		t.Fatal("an unverified email logs into the existing account")
+	}
+
+	cookie, state, nonce = o.login()
+	o.issuer.issue("code-2", jwt.MapClaims{"sub": "bob-sub", "email": "bob@example.com", "email_verified": true, "nonce": nonce})
+	resp = o.callback(cookie, url.Values{"code": {"code-2"}, "state": {state}})
+	if findCookie(resp, "auth_token") == nil {
+		t.Fatalf("verified email: no session, redirected to %s", resp.Header.Get("Location"))
+	}
+
+	var link models.UserIdentity
+	if err := o.h.db.Where("provider = ? AND subject = ?", "mock", "bob-sub").First(&link).Error; err != nil {
+		t.Fatalf("identity not linked: %v", err)
+	}
+	if link.UserID != existing.ID {
+		t.Errorf("identity linked to user %d, want the existing user %d", link.UserID, existing.ID)
+	}
+	var count int64
+	o.h.db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("%d users, want the existing one only", count)
+	}
+
+	// later logins go through the link, whatever the email
+	cookie, state, nonce = o.login()
+	o.issuer.issue("code-3", jwt.MapClaims{"sub": "bob-sub", "email": "robert@example.com", "nonce": nonce})
+	resp = o.callback(cookie, url.Values{"code": {"code-3"}, "state": {state}})
+	if findCookie(resp, "auth_token") == nil {
+		t.Errorf("linked identity: no session, redirected to %s", resp.Header.Get("Location"))
+	}
+}
+
+func TestOIDCCallbackProviderError(t *testing.T) {
+	o := newOIDCTest(t)
+	for _, tc := range []struct{ sent, want string }{
+		{"access_denied", "access_denied"},
+		{"access_denied&next=//evil.example#x", "sso_failed"},
+		{"unheard_of", "sso_failed"},
+	} {
+		cookie, state, _ := o.login()
+		resp := o.callback(cookie, url.Values{"error": {tc.sent}, "state": {state}})
+		if got, want := resp.Header.Get("Location"), o.cfg.Server.FrontendURL+"/login?error="+tc.want; got != want {
+			t.Errorf("error %q redirects to %q, want %q", tc.sent, got, want)
+		}
+	}
+}
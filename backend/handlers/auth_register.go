@@ -6,8 +6,8 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/plugin/dbresolver"
 
-	"blog-app-backend/config"
 	"blog-app-backend/models"
 )
 
@@ -20,7 +20,10 @@ type RegisterRequest struct {
 
 var validate = validator.New()
 
-func Register(c *fiber.Ctx) error {
+// Register → POST /auth/register
+func (h *AuthHandler) Register(c *fiber.Ctx) error {
+	db := h.db.WithContext(c.UserContext())
+
 	// 1) parse JSON into our DTO (JSON transform to struct do every time)
 	var req RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -32,9 +35,10 @@ func Register(c *fiber.Ctx) error {
 		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// 3) check duplicates (username or email must be unique)
+	// 3) check duplicates (username or email must be unique), on the
+	// primary: a replica may not have seen a registration yet
 	var cnt int64
-	if err := config.DB.Model(&models.User{}).
+	if err := db.Clauses(dbresolver.Write).Model(&models.User{}).
 		Where("username = ? OR email = ?", req.Username, req.Email).
 		Count(&cnt).Error; err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
@@ -58,7 +62,7 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	// 6) insert into DB
-	if err := config.DB.Create(&user).Error; err != nil {
+	if err := db.Create(&user).Error; err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "insert error"})
 	}
 
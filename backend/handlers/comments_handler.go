@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+type CreateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=5000"`
+}
+
+// PublicComment is a comment as anonymous readers see it
+type PublicComment struct {
+	ID        uint      `json:"id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PublicCommentsResponse struct {
+	Items    []PublicComment `json:"items"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// CommentsHandler serves the comments on published posts
+type CommentsHandler struct {
+	db        *gorm.DB
+	moderator services.Moderator
+}
+
+func NewCommentsHandler(a *app.App) *CommentsHandler {
+	return &CommentsHandler{db: a.DB, moderator: a.Moderator}
+}
+
+// ListComments → GET /public/posts/:id/comments
+//
+// Oldest first, paged like the other public listings
+func (h *CommentsHandler) ListComments(c *fiber.Ctx) error {
+	post, ok := h.findPublishedPost(c)
+	if !ok {
+		return nil
+	}
+	page, pageSize := publicPage(c)
+
+	db := h.db.WithContext(c.UserContext()).Model(&models.Comment{}).Where("post_id = ?", post.ID)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	var comments []models.Comment
+	if err := db.Order("created_at").Order("id").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&comments).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	items := make([]PublicComment, len(comments))
+	for i, comment := range comments {
+		items[i] = PublicComment{ID: comment.ID, Author: comment.Author, Content: comment.Content, CreatedAt: comment.CreatedAt}
+	}
+	return c.Status(http.StatusOK).JSON(PublicCommentsResponse{Items: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// CreateComment → POST /posts/:id/comments
+func (h *CommentsHandler) CreateComment(c *fiber.Ctx) error {
+	// 1) only published posts take comments
+	post, ok := h.findPublishedPost(c)
+	if !ok {
+		return nil
+	}
+
+	// 2) parse, validate and moderate like a post
+	var req CreateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+	if err := postValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	isClean, err := h.moderator.CheckContent(c.UserContext(), "", req.Content)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Content filtering service unavailable. Please try again later."})
+	}
+	if !isClean {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Your comment contains inappropriate content or offensive language. Please review and modify it before posting."})
+	}
+
+	// 3) the comment is signed with the account's username
+	var user models.User
+	if err := h.db.WithContext(c.UserContext()).First(&user, middleware.CurrentUserID(c)).Error; err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	comment := models.Comment{PostID: post.ID, UserID: user.ID, Author: user.Username, Content: req.Content}
+
+	// 4) save it and count it on the post together
+	err = h.db.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return adjustCommentCount(tx, post.ID, 1)
+	})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not create comment"})
+	}
+	return c.Status(http.StatusCreated).JSON(comment)
+}
+
+// DeleteComment → DELETE /posts/:id/comments/:comment_id
+//
+// The comment's author, the post's author and admins may delete it
+func (h *CommentsHandler) DeleteComment(c *fiber.Ctx) error {
+	postID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid post id"})
+	}
+	commentID, err := strconv.ParseUint(c.Params("comment_id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid comment id"})
+	}
+
+	db := h.db.WithContext(c.UserContext())
+	var comment models.Comment
+	if err := db.Where("post_id = ?", postID).First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "comment not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	userID := middleware.CurrentUserID(c)
+	allowed := comment.UserID == userID || middleware.CurrentRole(c) == models.RoleAdmin
+	if !allowed {
+		var post models.Post
+		if err := db.Unscoped().Select("user_id").First(&post, postID).Error; err == nil {
+			allowed = post.UserID != nil && *post.UserID == userID
+		}
+	}
+	if !allowed {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "you can only delete your own comments or comments on your posts"})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		return adjustCommentCount(tx, comment.PostID, -1)
+	})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete comment"})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "comment deleted"})
+}
+
+// findPublishedPost loads the published post in the :id parameter. When it
+// returns false the error response has been sent.
+func (h *CommentsHandler) findPublishedPost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "post not found"})
+		return post, false
+	}
+	err = h.db.WithContext(c.UserContext()).Select("id").
+		Where("published = ?", true).First(&post, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "post not found"})
+		} else {
+			c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+		return post, false
+	}
+	return post, true
+}
+
+// adjustCommentCount adds delta to the post's comment_count. Like
+// countView it is a raw statement, so the post's updated_at stays as it
+// is and the search index and sitemaps don't see the post as edited.
+func adjustCommentCount(tx *gorm.DB, postID uint, delta int) error {
+	return tx.Exec("UPDATE posts SET comment_count = comment_count + ? WHERE id = ?", delta, postID).Error
+}
+
+// countView adds a view to the post
+func countView(db *gorm.DB, postID uint) error {
+	return db.Exec("UPDATE posts SET view_count = view_count + 1 WHERE id = ?", postID).Error
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/services"
+)
+
+// allowAll passes everything through moderation
+type allowAll struct{}
+
+func (allowAll) CheckContent(context.Context, string, string) (bool, error) { return true, nil }
+
+type commentsTest struct {
+	t      *testing.T
+	a      *app.App
+	server *fiber.App
+}
+
+func newCommentsTest(t *testing.T) *commentsTest {
+	a := newTestApp(t, nil)
+	a.Moderator = allowAll{}
+	server := fiber.New()
+	public := NewPublicHandler(a)
+	comments := NewCommentsHandler(a)
+	server.Get("/api/public/posts", public.ListPosts)
+	server.Get("/api/public/posts/:id", public.GetPost)
+	server.Get("/api/public/posts/:id/comments", comments.ListComments)
+	protected := server.Group("/api", middleware.JWTProtected(a.Sessions, a.Audit))
+	protected.Post("/posts/:id/comments", comments.CreateComment)
+	protected.Delete("/posts/:id/comments/:comment_id", comments.DeleteComment)
+	return &commentsTest{t: t, a: a, server: server}
+}
+
+func (ct *commentsTest) user(name string) (models.User, string) {
+	user := models.User{Username: name, Email: name + "@example.com", Password: "x", Role: models.RoleUser, IsActive: true}
+	if err := ct.a.DB.Create(&user).Error; err != nil {
+		ct.t.Fatal(err)
+	}
+	_, token, err := ct.a.Sessions.Start(context.Background(), user, services.NewSession{})
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	return user, token
+}
+
+func (ct *commentsTest) do(method, path, token, body string, out any) int {
+	ct.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ct.server.Test(req, -1)
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			ct.t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func (ct *commentsTest) post(title string, userID *uint) models.Post {
+	post := models.Post{Title: title, Content: "text", Author: "ann", UserID: userID, Published: true}
+	if err := ct.a.DB.Create(&post).Error; err != nil {
+		ct.t.Fatal(err)
+	}
+	return post
+}
+
+func TestComments(t *testing.T) {
+	ct := newCommentsTest(t)
+	ann, annToken := ct.user("ann")
+	_, bobToken := ct.user("bob")
+	_, carlToken := ct.user("carl")
+	post := ct.post("first", &ann.ID)
+	path := fmt.Sprintf("/api/posts/%d/comments", post.ID)
+
+	var created models.Comment
+	if status := ct.do(http.MethodPost, path, bobToken, `{"content":"nice post"}`, &created); status != http.StatusCreated {
+		t.Fatalf("commenting: status %d, want 201", status)
+	}
+	if created.Author != "bob" {
+		t.Errorf("comment author = %q, want the commenter's username", created.Author)
+	}
+	ct.do(http.MethodPost, path, carlToken, `{"content":"agreed"}`, nil)
+
+	var list PublicCommentsResponse
+	ct.do(http.MethodGet, fmt.Sprintf("/api/public/posts/%d/comments", post.ID), "", "", &list)
+	if list.Total != 2 || len(list.Items) != 2 || list.Items[0].Content != "nice post" {
+		t.Errorf("comments = %+v, want both, oldest first", list)
+	}
+
+	// counting comments leaves the post as it was
+	var stored models.Post
+	ct.a.DB.First(&stored, post.ID)
+	if stored.CommentCount != 2 || !stored.UpdatedAt.Equal(post.UpdatedAt) {
+		t.Errorf("comment_count = %d, updated_at %v; want 2 and unchanged", stored.CommentCount, stored.UpdatedAt)
+	}
+
+	// carl may not delete bob's comment; the post's author may
+	one := fmt.Sprintf("%s/%d", path, created.ID)
+	if status := ct.do(http.MethodDelete, one, carlToken, "", nil); status != http.StatusForbidden {
+		t.Errorf("deleting someone else's comment: status %d, want 403", status)
+	}
+	if status := ct.do(http.MethodDelete, one, annToken, "", nil); status != http.StatusOK {
+		t.Errorf("deleting a comment on your post: status %d, want 200", status)
+	}
+	ct.a.DB.First(&stored, post.ID)
+	if stored.CommentCount != 1 {
+		t.Errorf("comment_count = %d after a delete, want 1", stored.CommentCount)
+	}
+
+	// drafts take no comments
+	draft := ct.post("draft", &ann.ID)
+	ct.a.DB.Model(&draft).Update("published", false)
+	if status := ct.do(http.MethodPost, fmt.Sprintf("/api/posts/%d/comments", draft.ID), bobToken, `{"content":"hi"}`, nil); status != http.StatusNotFound {
+		t.Errorf("commenting on a draft: status %d, want 404", status)
+	}
+}
+
+func TestSortByViewsAndComments(t *testing.T) {
+	ct := newCommentsTest(t)
+	_, token := ct.user("bob")
+	ct.post("quiet", nil)
+	read := ct.post("read", nil)
+	discussed := ct.post("discussed", nil)
+
+	for range 3 {
+		ct.do(http.MethodGet, fmt.Sprintf("/api/public/posts/%d", read.ID), "", "", nil)
+	}
+	var got PublicPost
+	ct.do(http.MethodGet, fmt.Sprintf("/api/public/posts/%d", discussed.ID), "", "", &got)
+	if got.ViewCount != 1 {
+		t.Errorf("view_count = %d on the first read, want 1", got.ViewCount)
+	}
+	for range 2 {
+		ct.do(http.MethodPost, fmt.Sprintf("/api/posts/%d/comments", discussed.ID), token, `{"content":"hm"}`, nil)
+	}
+
+	// page one post at a time, so the cursors carry the counts
+	titles := func(sort string) []string {
+		var out []string
+		query := url.Values{"sort": {sort}, "page_size": {"1"}, "cursor": {""}}
+		for range 4 {
+			var page struct {
+				Items      []PublicPost `json:"items"`
+				NextCursor string       `json:"next_cursor"`
+			}
+			if status := ct.do(http.MethodGet, "/api/public/posts?"+query.Encode(), "", "", &page); status != http.StatusOK {
+				t.Fatalf("sort=%s: status %d", sort, status)
+			}
+			for _, p := range page.Items {
+				out = append(out, p.Title)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", page.NextCursor)
+		}
+		return out
+	}
+	if got, want := titles("-popularity"), []string{"read", "discussed", "quiet"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("sort=-popularity: %v, want %v", got, want)
+	}
+	if got, want := titles("-comments"), []string{"discussed", "read", "quiet"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("sort=-comments: %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+)
+
+// DBStats reports the connection pools of the primary and the replicas
+type DBStats struct {
+	Timestamp time.Time             `json:"timestamp"`
+	Primary   config.PoolStats      `json:"primary"`
+	Replicas  []config.ReplicaStats `json:"replicas"`
+}
+
+// DBStatsHandler serves the database pool statistics
+type DBStatsHandler struct {
+	app *app.App
+}
+
+func NewDBStatsHandler(a *app.App) *DBStatsHandler {
+	return &DBStatsHandler{app: a}
+}
+
+// GetDBStats → GET /admin/debug/db/stats
+func (h *DBStatsHandler) GetDBStats(c *fiber.Ctx) error {
+	sqlDB, err := h.app.DB.DB()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	stats := DBStats{
+		Timestamp: time.Now(),
+		Primary:   config.NewPoolStats(sqlDB.Stats()),
+		Replicas:  []config.ReplicaStats{},
+	}
+	if h.app.Replicas != nil {
+		stats.Replicas = h.app.Replicas.Stats()
+	}
+
+	return c.Status(http.StatusOK).JSON(stats)
+}
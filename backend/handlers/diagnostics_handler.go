@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/services"
+)
+
+// DiagnosticsHandler serves the net/http/pprof profiles
+type DiagnosticsHandler struct {
+	audit      *services.AuditLog
+	maxSeconds int
+}
+
+func NewDiagnosticsHandler(a *app.App) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		audit:      a.Audit,
+		maxSeconds: int(a.Config.Diagnostics.MaxProfileDuration / time.Second),
+	}
+}
+
+// Index → GET /admin/debug/pprof/
+func (h *DiagnosticsHandler) Index(c *fiber.Ctx) error {
+	return serveHTTP(c, pprof.Index)
+}
+
+// Profile → GET /admin/debug/pprof/:name (heap, goroutine, block, mutex, allocs, threadcreate)
+func (h *DiagnosticsHandler) Profile(c *fiber.Ctx) error {
+	switch name := c.Params("name"); name {
+	case "heap", "goroutine", "block", "mutex", "allocs", "threadcreate":
+		return serveHTTP(c, pprof.Handler(name).ServeHTTP)
+	case "cmdline":
+		return serveHTTP(c, pprof.Cmdline)
+	case "symbol":
+		return serveHTTP(c, pprof.Symbol)
+	}
+	return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "unknown profile"})
+}
+
+// CPUProfile → GET /admin/debug/pprof/profile?seconds=N
+func (h *DiagnosticsHandler) CPUProfile(c *fiber.Ctx) error {
+	if !h.boundSeconds(c, "debug.cpu_profile") {
+		return nil
+	}
+	return serveHTTP(c, pprof.Profile)
+}
+
+// Trace → GET /admin/debug/pprof/trace?seconds=N
+// Execution traces are heavy, so their duration is capped like CPU profiles
+func (h *DiagnosticsHandler) Trace(c *fiber.Ctx) error {
+	if !h.boundSeconds(c, "debug.trace") {
+		return nil
+	}
+	return serveHTTP(c, pprof.Trace)
+}
+
+// boundSeconds rejects a "seconds" parameter over the configured maximum and
+// records the capture in the audit log. When it returns false the error
+// response has already been written.
+func (h *DiagnosticsHandler) boundSeconds(c *fiber.Ctx, action string) bool {
+	seconds := 1
+	if raw := c.Query("seconds"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "seconds must be a positive number"})
+			return false
+		}
+		seconds = n
+	}
+	if seconds > h.maxSeconds {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "seconds can't exceed " + strconv.Itoa(h.maxSeconds)})
+		return false
+	}
+
+	// pprof reads the parameter itself; make the default explicit
+	c.Request().URI().QueryArgs().Set("seconds", strconv.Itoa(seconds))
+
+	h.audit.Record(middleware.CurrentUserID(c), action, nil, c.IP(), map[string]interface{}{
+		"seconds": seconds,
+	})
+	return true
+}
+
+// serveHTTP runs a net/http handler on the Fiber request
+func serveHTTP(c *fiber.Ctx, handler http.HandlerFunc) error {
+	fasthttpadaptor.NewFastHTTPHandlerFunc(handler)(c.Context())
+	return nil
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/feed"
+	"blog-app-backend/models"
+)
+
+// feedSummaryLength is the length of item summaries in bytes
+const feedSummaryLength = 300
+
+// feedFormats are the formats a feed can be requested in, by extension
+var feedFormats = map[string]struct {
+	contentType string
+	render      func(feed.Feed) ([]byte, error)
+}{
+	"rss":  {feed.RSSContentType, feed.RSS},
+	"atom": {feed.AtomContentType, feed.Atom},
+	"json": {feed.JSONContentType, feed.JSON},
+}
+
+// FeedHandler serves RSS, Atom and JSON feeds of the published posts. It
+// is only routed when the backend serves pages: the feeds, and the posts
+// they link to, are reached at pages.base_url.
+type FeedHandler struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewFeedHandler(a *app.App) *FeedHandler {
+	return &FeedHandler{db: a.DB, cfg: a.Config}
+}
+
+// Feed → GET /feed.:format, /authors/:author/feed.:format, /tags/:tag/feed.:format
+//
+// The format is rss, atom or json; ?content=full or ?content=summary
+// overrides the configured content
+func (h *FeedHandler) Feed(c *fiber.Ctx) error {
+	format, ok := feedFormats[c.Params("format")]
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "unknown feed format"})
+	}
+	content := c.Query("content", h.cfg.Feed.Content)
+	if content != config.FeedContentFull && content != config.FeedContentSummary {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "content must be full or summary"})
+	}
+
+	// 1) which posts: all, one author's or one tag's
+	db := h.db.WithContext(c.UserContext())
+	title := h.cfg.Feed.Title
+	query := db.Model(&models.Post{}).Where("published = ?", true)
+	variant := "all"
+	author, _ := url.PathUnescape(c.Params("author"))
+	if author != "" {
+		query = query.Where("author = ?", author)
+		title += " – " + author
+		variant = "author\x00" + author
+	}
+	if raw, _ := url.PathUnescape(c.Params("tag")); raw != "" {
+		tags, err := normalizeTags([]string{raw})
+		if err != nil || len(tags) != 1 {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "tag not found"})
+		}
+		var tag models.Tag
+		if err := db.Where("name = ?", tags[0]).First(&tag).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "tag not found"})
+			}
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+		query = query.Where("id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Table("post_tags").Select("post_id").Where("tag_id = ?", tag.ID))
+		title += " – #" + tag.Name
+		variant = "tag\x00" + tag.Name
+	}
+
+	// 2) ids and change times first: enough to answer conditional requests
+	var stamps []models.Post
+	err := query.Select("id", "updated_at").
+		Order("created_at DESC").Order("id DESC").
+		Limit(h.cfg.Feed.Items).Find(&stamps).Error
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if len(stamps) == 0 && author != "" {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "author not found"})
+	}
+
+	etag, lastModified := feedValidators(c.Params("format"), variant, content, title, stamps)
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(h.cfg.Feed.MaxAge.Seconds())))
+	c.Vary(fiber.HeaderAcceptEncoding)
+	if notModified(c, etag, lastModified) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	// 3) the posts themselves
+	ids := make([]uint, len(stamps))
+	for i, stamp := range stamps {
+		ids[i] = stamp.ID
+	}
+	var posts []models.Post
+	if len(ids) > 0 {
+		err := db.Preload("Tags").Where("id IN ?", ids).
+			Order("created_at DESC").Order("id DESC").Find(&posts).Error
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+	}
+
+	// 4) render
+	f := feed.Feed{
+		Title:       title,
+		Description: h.cfg.Feed.Description,
+		HomeURL:     pagesBaseURL(h.cfg) + "/",
+		FeedURL:     pagesBaseURL(h.cfg) + c.Path(),
+		Updated:     lastModified,
+	}
+	for _, post := range posts {
+		item := feed.Item{
+			ID:        postURL(h.cfg, post.ID),
+			Title:     post.Title,
+			Author:    post.Author,
+			Summary:   feed.Summarize(post.Content, feedSummaryLength),
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+		}
+		item.URL = item.ID
+		if content == config.FeedContentFull {
+			item.ContentHTML = feed.TextToHTML(post.Content)
+		}
+		for _, tag := range post.Tags {
+			item.Tags = append(item.Tags, tag.Name)
+		}
+		f.Items = append(f.Items, item)
+	}
+	body, err := format.render(f)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not render feed"})
+	}
+
+	c.Set(fiber.HeaderContentType, format.contentType)
+	return c.Status(http.StatusOK).Send(body)
+}
+
+// feedValidators derives the ETag from everything the feed is rendered
+// from except the post bodies, whose changes show in updated_at. A deleted
+// post changes the ids, so the ETag changes even if Last-Modified cannot.
+func feedValidators(format, variant, content, title string, stamps []models.Post) (string, time.Time) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", format, variant, content, title)
+	var lastModified time.Time
+	for _, stamp := range stamps {
+		fmt.Fprintf(h, "%d:%d\x00", stamp.ID, stamp.UpdatedAt.UnixNano())
+		if stamp.UpdatedAt.After(lastModified) {
+			lastModified = stamp.UpdatedAt
+		}
+	}
+	return `W/"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16]) + `"`, lastModified
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// no If-None-Match (RFC 9110, section 13.2.2)
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// weak comparison
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
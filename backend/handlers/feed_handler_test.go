@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/config"
+	"blog-app-backend/models"
+)
+
+func TestFeedConditionalGet(t *testing.T) {
+	a := newTestApp(t, nil)
+	server := fiber.New()
+	server.Get("/feed.:format", NewFeedHandler(a).Feed)
+
+	post := models.Post{Title: "first", Content: "hello", Author: "ann", Published: true}
+	if err := a.DB.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(header, value string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/feed.rss", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := server.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	first := get("", "")
+	etag := first.Header.Get(fiber.HeaderETag)
+	if first.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("status %d, ETag %q; want 200 with an ETag", first.StatusCode, etag)
+	}
+
+	if resp := get(fiber.HeaderIfNoneMatch, etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match with the current ETag: status %d, want 304", resp.StatusCode)
+	}
+	if resp := get(fiber.HeaderIfNoneMatch, `"other", `+etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match listing the current ETag: status %d, want 304", resp.StatusCode)
+	}
+	lastModified := first.Header.Get(fiber.HeaderLastModified)
+	if resp := get(fiber.HeaderIfModifiedSince, lastModified); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-Modified-Since with Last-Modified: status %d, want 304", resp.StatusCode)
+	}
+
+	// a new post changes the feed
+	if err := a.DB.Create(&models.Post{Title: "second", Content: "again", Author: "ann", Published: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	resp := get(fiber.HeaderIfNoneMatch, etag)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("If-None-Match with a stale ETag: status %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderETag); got == etag {
+		t.Error("the ETag did not change with the feed")
+	}
+}
+
+func TestFeedLinksIgnoreHost(t *testing.T) {
+	a := newTestApp(t, func(cfg *config.Config) {
+		cfg.Server.FrontendURL = "https://app.example.com"
+		cfg.Pages.Enabled = true
+		cfg.Pages.BaseURL = "https://blog.example.com/"
+	})
+	server := fiber.New()
+	server.Get("/feed.:format", NewFeedHandler(a).Feed)
+	post := models.Post{Title: "first", Content: "hello", Author: "ann", Published: true}
+	if err := a.DB.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.json", nil)
+	req.Host = "evil.example.net"
+	resp, err := server.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(body), "evil.example.net") {
+		t.Errorf("the feed links to the request's Host:\n%s", body)
+	}
+	// the feed, its home and its items are all on the pages' host
+	for _, want := range []string{
+		`"feed_url": "https://blog.example.com/feed.json"`,
+		`"home_page_url": "https://blog.example.com/"`,
+		`"url": "https://blog.example.com/posts/` + strconv.Itoa(int(post.ID)) + `"`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("no %s in:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/services"
+)
+
+// healthCheckTimeout bounds each dependency check of /readyz
+const healthCheckTimeout = 2 * time.Second
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	app *app.App
+}
+
+func NewHealthHandler(a *app.App) *HealthHandler {
+	return &HealthHandler{app: a}
+}
+
+// Livez → GET /livez
+// The process is up and serving requests; dependencies are not checked
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "ok"})
+}
+
+// Readyz → GET /readyz
+// The app can serve traffic: it is not shutting down and its dependencies answer
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	if !h.app.Ready() {
+		return c.Status(http.StatusServiceUnavailable).JSON(fiber.Map{"status": "shutting down"})
+	}
+
+	checks, ok := h.check(c.UserContext(), middleware.Logger(c))
+	status, code := "ready", http.StatusOK
+	if !ok {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+	return c.Status(code).JSON(fiber.Map{"status": status, "checks": checks})
+}
+
+// Health → GET /health
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	if err := h.pingDB(c.UserContext()); err != nil {
+		return c.Status(http.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":   "unhealthy",
+			"database": "unreachable",
+		})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":   "healthy",
+		"database": "connected",
+	})
+}
+
+// check pings every dependency and reports "ok" or "unavailable" for each.
+// Errors are only logged: /readyz is public, and they can name hosts and
+// drivers.
+func (h *HealthHandler) check(ctx context.Context, logger *slog.Logger) (map[string]string, bool) {
+	checks := map[string]string{}
+	ok := true
+	record := func(name string, err error) {
+		if err != nil {
+			logger.Warn("readiness check failed", "check", name, "error", err)
+			checks[name] = "unavailable"
+			ok = false
+		} else {
+			checks[name] = "ok"
+		}
+	}
+
+	record("database", h.pingDB(ctx))
+
+	if h.app.Config.Moderation.ReadinessCheck {
+		if pinger, isPinger := h.app.Moderator.(services.Pinger); isPinger {
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			record("moderation", pinger.Ping(ctx))
+			cancel()
+		}
+	}
+
+	return checks, ok
+}
+
+func (h *HealthHandler) pingDB(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	return h.app.PingDB(ctx)
+}
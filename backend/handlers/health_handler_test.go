@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReadyzHidesErrors(t *testing.T) {
+	a := newTestApp(t, nil)
+	server := fiber.New()
+	server.Get("/readyz", NewHealthHandler(a).Readyz)
+
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+
+	resp, err := server.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503", resp.StatusCode)
+	}
+	var body struct {
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Checks["database"]; got != "unavailable" {
+		t.Errorf("database check = %q, want \"unavailable\"", got)
+	}
+}
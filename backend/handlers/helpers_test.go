@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/migrations"
+)
+
+// newTestApp builds an app on a migrated SQLite database of its own, with
+// a fresh signing key; configure adjusts the defaults before it is built
+func newTestApp(t *testing.T, configure func(*config.Config)) *app.App {
+	t.Helper()
+
+	cfg := config.Defaults()
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.Path = filepath.Join(t.TempDir(), "blog.db")
+	cfg.JWT.KeysDir = t.TempDir()
+	writeTestKey(t, filepath.Join(cfg.JWT.KeysDir, "test.pem"))
+	if configure != nil {
+		configure(&cfg)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a, err := app.New(&cfg, app.WithLogger(logger))
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	if err := migrations.New(a.DB, logger).Up(); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	return a
+}
+
+func writeTestKey(t *testing.T, file string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
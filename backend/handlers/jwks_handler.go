@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS → GET /.well-known/jwks.json
+// Publishes the public keys so other services can verify our tokens
+func (h *AuthHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(http.StatusOK).JSON(fiber.Map{"keys": h.keys.JWKS()})
+}
@@ -1,47 +1,30 @@
 package handlers
 
 import (
-	"blog-app-backend/middleware"
+	"bytes"
+	"fmt"
 	"net/http"
 	"runtime"
+	"runtime/pprof"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-)
 
-// DetailedMemoryStats includes additional system information
-type DetailedMemoryStats struct {
-	middleware.MemoryStats
-	Timestamp    time.Time `json:"timestamp"`
-	Goroutines   int       `json:"goroutines"`
-	CPUCount     int       `json:"cpu_count"`
-	GoVersion    string    `json:"go_version"`
-	AllocMB      float64   `json:"alloc_mb"`
-	SysMB        float64   `json:"sys_mb"`
-	HeapAllocMB  float64   `json:"heap_alloc_mb"`
-	HeapSysMB    float64   `json:"heap_sys_mb"`
-}
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/monitor"
+	"blog-app-backend/services"
+)
 
-// GetMemoryStats returns current memory statistics
+// GetMemoryStats → GET /admin/debug/memory
+// Returns current memory statistics
 func GetMemoryStats(c *fiber.Ctx) error {
-	stats := middleware.GetMemoryStats()
-
-	detailed := DetailedMemoryStats{
-		MemoryStats: stats,
-		Timestamp:   time.Now(),
-		Goroutines:  runtime.NumGoroutine(),
-		CPUCount:    runtime.NumCPU(),
-		GoVersion:   runtime.Version(),
-		AllocMB:     middleware.BytesToMB(stats.Alloc),
-		SysMB:       middleware.BytesToMB(stats.Sys),
-		HeapAllocMB: middleware.BytesToMB(stats.HeapAlloc),
-		HeapSysMB:   middleware.BytesToMB(stats.HeapSys),
-	}
-
-	return c.Status(http.StatusOK).JSON(detailed)
+	return c.Status(http.StatusOK).JSON(middleware.GetDetailedMemoryStats())
 }
 
-// ForceGC forces garbage collection and returns memory stats
+// ForceGC → POST /admin/debug/memory/gc
+// Forces garbage collection and returns memory stats
 func ForceGC(c *fiber.Ctx) error {
 	beforeStats := middleware.GetMemoryStats()
 
@@ -67,4 +50,77 @@ func ForceGC(c *fiber.Ctx) error {
 	}
 
 	return c.Status(http.StatusOK).JSON(response)
-}
\ No newline at end of file
+}
+
+type MemoryHistoryResponse struct {
+	Interval time.Duration                    `json:"interval_ns"`
+	Samples  []middleware.DetailedMemoryStats `json:"samples"`
+	Alerts   []monitor.MemoryAlert            `json:"alerts"`
+}
+
+// MemoryHandler serves the sampled memory history and heap profiles
+type MemoryHandler struct {
+	sampler  *monitor.MemorySampler
+	audit    *services.AuditLog
+	interval time.Duration
+}
+
+func NewMemoryHandler(a *app.App) *MemoryHandler {
+	return &MemoryHandler{
+		sampler:  a.Memory,
+		audit:    a.Audit,
+		interval: a.Config.Memory.SampleInterval,
+	}
+}
+
+// GetMemoryHistory → GET /admin/debug/memory/history?since=1h&points=300
+func (h *MemoryHandler) GetMemoryHistory(c *fiber.Ctx) error {
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "since must be a positive duration, e.g. 1h"})
+		}
+		since = time.Now().Add(-d)
+	}
+
+	points, _ := strconv.Atoi(c.Query("points", "300"))
+	if points < 1 || points > 5000 {
+		points = 300
+	}
+
+	samples := h.sampler.History(since, points)
+	interval := h.interval
+	if n := len(samples); n > 1 {
+		// after downsampling the points are further apart than the sample interval
+		interval = samples[n-1].Timestamp.Sub(samples[0].Timestamp) / time.Duration(n-1)
+	}
+
+	return c.Status(http.StatusOK).JSON(MemoryHistoryResponse{
+		Interval: interval,
+		Samples:  samples,
+		Alerts:   h.sampler.Alerts(),
+	})
+}
+
+// CaptureHeapProfile → GET /admin/debug/memory/heap-profile?gc=true
+// Returns a pprof heap profile, for `go tool pprof`
+func (h *MemoryHandler) CaptureHeapProfile(c *fiber.Ctx) error {
+	if c.QueryBool("gc") {
+		runtime.GC()
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not capture heap profile"})
+	}
+
+	h.audit.Record(middleware.CurrentUserID(c), "debug.heap_profile", nil, c.IP(), map[string]interface{}{
+		"bytes": buf.Len(),
+	})
+
+	filename := fmt.Sprintf("heap-%s.pprof", time.Now().Format("20060102-150405"))
+	c.Set(fiber.HeaderContentType, "application/octet-stream")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Status(http.StatusOK).Send(buf.Bytes())
+}
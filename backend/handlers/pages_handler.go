@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/feed"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/pagination"
+	"blog-app-backend/theme"
+)
+
+// archivePageSize is how many posts one archive page lists
+const archivePageSize = 100
+
+// PagesHandler serves the server-rendered public pages, for crawlers and
+// readers without JavaScript
+type PagesHandler struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	theme *theme.Theme
+}
+
+func NewPagesHandler(a *app.App) *PagesHandler {
+	return &PagesHandler{db: a.DB, cfg: a.Config, theme: a.Theme}
+}
+
+// pagesBaseURL is where the pages are reached, without a trailing slash.
+// It never comes from the request: the Host header is the client's to set.
+func pagesBaseURL(cfg *config.Config) string {
+	return strings.TrimRight(cfg.Pages.BaseURL, "/")
+}
+
+// postURL is where a post is read: its page
+func postURL(cfg *config.Config, id uint) string {
+	return fmt.Sprintf("%s/posts/%d", pagesBaseURL(cfg), id)
+}
+
+// Home → GET /
+func (h *PagesHandler) Home(c *fiber.Ctx) error {
+	data := h.data(c)
+	data.Title = h.cfg.Feed.Title
+	data.Meta.Description = h.cfg.Feed.Description
+	return h.renderList(c, theme.PageHome, postFilters{}, data)
+}
+
+// Tag → GET /tags/:tag
+func (h *PagesHandler) Tag(c *fiber.Ctx) error {
+	raw, _ := url.PathUnescape(c.Params("tag"))
+	tags, err := normalizeTags([]string{raw})
+	if err != nil || len(tags) != 1 {
+		return h.notFound(c)
+	}
+
+	data := h.data(c)
+	data.Tag = tags[0]
+	data.Meta.Title = "Posts tagged #" + data.Tag
+	data.Title = data.Meta.Title + " – " + h.cfg.Feed.Title
+	data.Meta.Description = fmt.Sprintf("Posts tagged #%s on %s", data.Tag, h.cfg.Feed.Title)
+	data.Feeds = append(variantFeeds(data.Site.URL+"/tags/"+url.PathEscape(data.Tag), "#"+data.Tag), data.Feeds...)
+	return h.renderList(c, theme.PageTag, postFilters{tags: tags}, data)
+}
+
+// Author → GET /authors/:author
+func (h *PagesHandler) Author(c *fiber.Ctx) error {
+	author, _ := url.PathUnescape(c.Params("author"))
+
+	data := h.data(c)
+	data.Author = author
+	data.Meta.Title = "Posts by " + author
+	data.Title = data.Meta.Title + " – " + h.cfg.Feed.Title
+	data.Meta.Description = fmt.Sprintf("Posts by %s on %s", author, h.cfg.Feed.Title)
+	data.Feeds = append(variantFeeds(data.Site.URL+"/authors/"+url.PathEscape(author), author), data.Feeds...)
+	return h.renderList(c, theme.PageAuthor, postFilters{author: author}, data)
+}
+
+// renderList renders a paged list of the posts the filters select. An
+// empty tag or author page is a 404, as is a page past the end.
+func (h *PagesHandler) renderList(c *fiber.Ctx, page string, filters postFilters, data *theme.Data) error {
+	filters.status = statusPublished
+	filters.order = pagination.Order{Column: "created_at", Desc: true}
+	db := filters.apply(h.db.WithContext(c.UserContext()).Model(&models.Post{})).Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).SendString("database error")
+	}
+	if total == 0 && page != theme.PageHome {
+		return h.notFound(c)
+	}
+	if !h.paginate(c, data, total, h.cfg.Pages.PageSize) {
+		return h.notFound(c)
+	}
+
+	var posts []models.Post
+	err := db.Preload("Tags").Scopes(pagination.Keyset(filters.order, nil)).
+		Limit(h.cfg.Pages.PageSize).Offset((data.Page - 1) * h.cfg.Pages.PageSize).
+		Find(&posts).Error
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).SendString("database error")
+	}
+	for _, post := range posts {
+		data.Posts = append(data.Posts, h.postView(c, data.Site, post))
+	}
+	return h.render(c, http.StatusOK, page, data)
+}
+
+// Post → GET /posts/:id
+func (h *PagesHandler) Post(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return h.notFound(c)
+	}
+	var post models.Post
+	err = h.db.WithContext(c.UserContext()).Preload("Tags").
+		Where("published = ?", true).First(&post, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return h.notFound(c)
+		}
+		return c.Status(http.StatusInternalServerError).SendString("database error")
+	}
+
+	data := h.data(c)
+	view := h.postView(c, data.Site, post)
+	data.Post = &view
+	data.Title = fmt.Sprintf("%s – %s", post.Title, h.cfg.Feed.Title)
+	data.Meta = theme.Meta{
+		Type:        "article",
+		Title:       post.Title,
+		Description: view.Summary,
+		URL:         view.URL,
+		Published:   view.Published,
+		Modified:    view.Modified,
+		Author:      post.Author,
+		Tags:        tagNames(post.Tags),
+		JSONLD:      theme.ArticleJSONLD(data.Site, view),
+	}
+	return h.render(c, http.StatusOK, theme.PagePost, data)
+}
+
+// Archive → GET /archive
+//
+// Every post, newest first, grouped by month
+func (h *PagesHandler) Archive(c *fiber.Ctx) error {
+	db := h.db.WithContext(c.UserContext()).Model(&models.Post{}).Where("published = ?", true)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).SendString("database error")
+	}
+
+	data := h.data(c)
+	data.Meta.Title = "Archive"
+	data.Title = "Archive – " + h.cfg.Feed.Title
+	data.Meta.Description = "Every post on " + h.cfg.Feed.Title
+	if !h.paginate(c, data, total, archivePageSize) {
+		return h.notFound(c)
+	}
+
+	var posts []models.Post
+	err := db.Select("id", "title", "author", "created_at", "updated_at").
+		Order("created_at DESC").Order("id DESC").
+		Limit(archivePageSize).Offset((data.Page - 1) * archivePageSize).
+		Find(&posts).Error
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).SendString("database error")
+	}
+	for _, post := range posts {
+		label := post.CreatedAt.Format("January 2006")
+		if n := len(data.Months); n == 0 || data.Months[n-1].Label != label {
+			data.Months = append(data.Months, theme.Month{Label: label})
+		}
+		month := &data.Months[len(data.Months)-1]
+		month.Posts = append(month.Posts, h.postView(c, data.Site, post))
+	}
+	return h.render(c, http.StatusOK, theme.PageArchive, data)
+}
+
+// data starts the page data for the current URL
+func (h *PagesHandler) data(c *fiber.Ctx) *theme.Data {
+	site := theme.Site{
+		Title:       h.cfg.Feed.Title,
+		Description: h.cfg.Feed.Description,
+		URL:         pagesBaseURL(h.cfg),
+	}
+	return &theme.Data{
+		Site: site,
+		Meta: theme.Meta{
+			Type:  "website",
+			Title: h.cfg.Feed.Title,
+			URL:   site.URL + c.Path(),
+		},
+		Feeds: variantFeeds(site.URL, h.cfg.Feed.Title),
+		Page:  1,
+		Pages: 1,
+	}
+}
+
+// paginate reads ?page= and fills in the page numbers and links, the
+// canonical URL included. It reports false for a page that does not exist.
+func (h *PagesHandler) paginate(c *fiber.Ctx, data *theme.Data, total int64, pageSize int) bool {
+	page := 1
+	if raw := c.Query("page"); raw != "" {
+		var err error
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			return false
+		}
+	}
+	pages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if pages < 1 {
+		pages = 1
+	}
+	if page > pages {
+		return false
+	}
+
+	pageURL := func(n int) string {
+		if n == 1 {
+			return data.Site.URL + c.Path()
+		}
+		return fmt.Sprintf("%s%s?page=%d", data.Site.URL, c.Path(), n)
+	}
+	data.Page, data.Pages = page, pages
+	data.Meta.URL = pageURL(page)
+	if page > 1 {
+		data.Prev = pageURL(page - 1)
+		data.Title = fmt.Sprintf("%s (page %d)", data.Title, page)
+	}
+	if page < pages {
+		data.Next = pageURL(page + 1)
+	}
+	return true
+}
+
+func (h *PagesHandler) postView(c *fiber.Ctx, site theme.Site, post models.Post) theme.Post {
+	view := theme.Post{
+		ID:        post.ID,
+		URL:       postURL(h.cfg, post.ID),
+		Title:     post.Title,
+		Author:    post.Author,
+		AuthorURL: site.URL + "/authors/" + url.PathEscape(post.Author),
+		Summary:   feed.Summarize(post.Content, feedSummaryLength),
+		Content:   template.HTML(feed.TextToHTML(post.Content)),
+		Published: post.CreatedAt,
+		Modified:  post.UpdatedAt,
+	}
+	for _, tag := range post.Tags {
+		view.Tags = append(view.Tags, theme.Link{
+			Title: tag.Name,
+			URL:   site.URL + "/tags/" + url.PathEscape(tag.Name),
+		})
+	}
+	return view
+}
+
+// variantFeeds links the three feeds under base
+func variantFeeds(base, title string) []theme.Link {
+	return []theme.Link{
+		{Title: title + " (RSS)", URL: base + "/feed.rss", Type: "application/rss+xml"},
+		{Title: title + " (Atom)", URL: base + "/feed.atom", Type: "application/atom+xml"},
+		{Title: title + " (JSON Feed)", URL: base + "/feed.json", Type: "application/feed+json"},
+	}
+}
+
+func (h *PagesHandler) notFound(c *fiber.Ctx) error {
+	data := h.data(c)
+	data.Title = "Not found – " + h.cfg.Feed.Title
+	return h.render(c, http.StatusNotFound, theme.PageNotFound, data)
+}
+
+func (h *PagesHandler) render(c *fiber.Ctx, status int, page string, data *theme.Data) error {
+	var buf bytes.Buffer
+	if err := h.theme.Render(&buf, page, data); err != nil {
+		middleware.Logger(c).Error("could not render page", "theme", h.theme.Name, "page", page, "error", err)
+		return c.Status(http.StatusInternalServerError).SendString("could not render page")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(status).Send(buf.Bytes())
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"blog-app-backend/models"
+)
+
+// passwordResetLifetime is how long an emailed reset link works
+const passwordResetLifetime = 24 * time.Hour
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+var resetValidator = validator.New()
+
+// sendPasswordReset creates a reset token for the user and emails the link
+func (h *AdminHandler) sendPasswordReset(ctx context.Context, user models.User) error {
+	token := randomToken()
+	reset := models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: time.Now().Add(passwordResetLifetime),
+	}
+	if err := h.db.WithContext(ctx).Create(&reset).Error; err != nil {
+		return err
+	}
+
+	link := frontendURL(h.cfg, "/reset-password?token="+url.QueryEscape(token))
+	body := fmt.Sprintf(`Hi %s,
+
+A password reset is required for your account. Choose a new password here:
+
+%s
+
+The link expires in %d hours.`, user.Username, link, int(passwordResetLifetime.Hours()))
+
+	return h.mailer.Send(user.Email, "Reset your password", body)
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ResetPassword → POST /auth/password-reset
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	db := h.db.WithContext(c.UserContext())
+
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+
+	if err := resetValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var reset models.PasswordReset
+	if err := db.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashResetToken(req.Token), time.Now()).
+		First(&reset).Error; err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid or expired reset token"})
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "hash error"})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&reset).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", reset.UserID).Updates(map[string]interface{}{
+			"password":                string(hash),
+			"password_reset_required": false,
+		}).Error; err != nil {
+			return err
+		}
+		// log out everywhere with the old password
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", reset.UserID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "password updated"})
+}
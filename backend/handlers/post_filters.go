@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/config"
+	"blog-app-backend/models"
+	"blog-app-backend/pagination"
+)
+
+// Post statuses for ?status=; anything but published is limited to the
+// current user's own posts
+const (
+	statusPublished = "published"
+	statusDraft     = "draft"
+	statusAll       = "all"
+)
+
+// postSorts are the columns GET /posts can be sorted by, with how to read
+// the column from a post for its cursor
+var postSorts = map[string]func(models.Post) any{
+	"created_at":    func(p models.Post) any { return p.CreatedAt },
+	"updated_at":    func(p models.Post) any { return p.UpdatedAt },
+	"title":         func(p models.Post) any { return p.Title },
+	"view_count":    func(p models.Post) any { return p.ViewCount },
+	"comment_count": func(p models.Post) any { return p.CommentCount },
+}
+
+// sortAliases are the names ?sort= accepts for columns of postSorts
+var sortAliases = map[string]string{
+	"popularity": "view_count",
+	"comments":   "comment_count",
+}
+
+// postFields are the names ?fields= accepts, with the column each is read
+// from; tags come from their own table
+var postFields = map[string]string{
+	"id":            "id",
+	"title":         "title",
+	"content":       "content",
+	"author":        "author",
+	"user_id":       "user_id",
+	"published":     "published",
+	"view_count":    "view_count",
+	"comment_count": "comment_count",
+	"created_at":    "created_at",
+	"updated_at":    "updated_at",
+	"tags":          "",
+}
+
+// postFilters is the parsed query of GET /posts:
+//
+//	sort=-created_at     created_at, updated_at, title, popularity (views)
+//	                     or comments; - for descending
+//	author=jane          exact author name
+//	tag=go,web-dev       posts with all of these tags
+//	from=2024-01-01      created on or after; a date or an RFC 3339 time
+//	to=2024-01-31        created on or before; a date includes the whole day
+//	status=published     published, or draft or all for your own posts
+//	fields=id,title      only these fields of each post
+type postFilters struct {
+	q      string
+	order  pagination.Order
+	author string
+	tags   []string
+	from   time.Time
+	to     time.Time
+	status string
+	// userID is whose drafts status=draft and status=all list
+	userID uint
+	fields []string
+	// public listings only show published posts, as PublicPost
+	public bool
+}
+
+func parsePostFilters(c *fiber.Ctx, userID uint) (postFilters, error) {
+	f := postFilters{
+		q:      strings.TrimSpace(c.Query("q")),
+		order:  pagination.Order{Column: "created_at", Desc: true},
+		author: strings.TrimSpace(c.Query("author")),
+		status: c.Query("status", statusPublished),
+		userID: userID,
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		column, desc := strings.CutPrefix(sort, "-")
+		if alias, ok := sortAliases[column]; ok {
+			column = alias
+		}
+		if _, ok := postSorts[column]; !ok {
+			return f, fmt.Errorf("cannot sort by %q, use one of created_at, updated_at, title, popularity or comments", column)
+		}
+		f.order = pagination.Order{Column: column, Desc: desc}
+	}
+
+	if raw := c.Query("tag"); raw != "" {
+		tags, err := normalizeTags(strings.Split(raw, ","))
+		if err != nil {
+			return f, err
+		}
+		f.tags = tags
+	}
+
+	var err error
+	if f.from, err = parseDateParam(c.Query("from"), false); err != nil {
+		return f, fmt.Errorf("from: %w", err)
+	}
+	if f.to, err = parseDateParam(c.Query("to"), true); err != nil {
+		return f, fmt.Errorf("to: %w", err)
+	}
+	if !f.from.IsZero() && !f.to.IsZero() && f.to.Before(f.from) {
+		return f, fmt.Errorf("to is before from")
+	}
+
+	switch f.status {
+	case statusPublished, statusDraft, statusAll:
+	default:
+		return f, fmt.Errorf("unknown status %q, use published, draft or all", f.status)
+	}
+
+	if raw := c.Query("fields"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := postFields[name]; !ok {
+				return f, fmt.Errorf("unknown field %q", name)
+			}
+			if !slices.Contains(f.fields, name) {
+				f.fields = append(f.fields, name)
+			}
+		}
+	}
+	return f, nil
+}
+
+// publicPostFields are the fields the public API has
+var publicPostFields = []string{"id", "title", "content", "author", "view_count", "comment_count", "created_at", "updated_at", "tags"}
+
+// parsePublicPostFilters is parsePostFilters for anonymous readers: only
+// published posts, and only the fields of PublicPost
+func parsePublicPostFilters(c *fiber.Ctx) (postFilters, error) {
+	f, err := parsePostFilters(c, 0)
+	if err != nil {
+		return f, err
+	}
+	if f.status != statusPublished {
+		return f, fmt.Errorf("only published posts are public")
+	}
+	for _, name := range f.fields {
+		if !slices.Contains(publicPostFields, name) {
+			return f, fmt.Errorf("unknown field %q", name)
+		}
+	}
+	f.public = true
+	return f, nil
+}
+
+// parseDateParam reads a date or an RFC 3339 time. For an end of range a
+// bare date means the end of that day.
+func parseDateParam(raw string, end bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date (2006-01-02) nor an RFC 3339 time", raw)
+	}
+	if end {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// key identifies the rows the filters select, in their order. Cursors and
+// cached counts are bound to it; the selected fields do not matter.
+func (f postFilters) key() string {
+	key := fmt.Sprintf("posts\x00%s\x00%s\x00%t\x00%s\x00%s\x00%s\x00%s\x00%s\x00",
+		f.q, f.order.Column, f.order.Desc, f.author, strings.Join(f.tags, ","),
+		formatBound(f.from), formatBound(f.to), f.status)
+	if f.status != statusPublished {
+		key += fmt.Sprint(f.userID)
+	}
+	return key
+}
+
+func formatBound(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// apply adds the filters to a query on posts
+func (f postFilters) apply(db *gorm.DB) *gorm.DB {
+	switch f.status {
+	case statusPublished:
+		db = db.Where("published = ?", true)
+	case statusDraft:
+		db = db.Where("published = ? AND user_id = ?", false, f.userID)
+	case statusAll:
+		db = db.Where("user_id = ?", f.userID)
+	}
+
+	if f.q != "" {
+		db = db.Scopes(config.ContainsAny(f.q, "title", "content"))
+	}
+	if f.author != "" {
+		db = db.Where("author = ?", f.author)
+	}
+	for _, tag := range f.tags {
+		db = db.Where("id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name = ?", tag))
+	}
+	if !f.from.IsZero() {
+		db = db.Where("created_at >= ?", f.from)
+	}
+	if !f.to.IsZero() {
+		db = db.Where("created_at <= ?", f.to)
+	}
+	return db
+}
+
+// columns are the columns to load: the selected fields plus what paging
+// needs, or nil for all of them
+func (f postFilters) columns() []string {
+	if f.fields == nil {
+		return nil
+	}
+	columns := []string{"id", f.order.Column}
+	for _, name := range f.fields {
+		if column := postFields[name]; column != "" && !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// wantsTags reports whether the tags need loading
+func (f postFilters) wantsTags() bool {
+	return f.fields == nil || slices.Contains(f.fields, "tags")
+}
+
+// project returns the posts as they are sent: whole, or only the selected
+// fields of each
+func (f postFilters) project(posts []models.Post) any {
+	if f.fields == nil {
+		if f.public {
+			return publicPosts(posts)
+		}
+		return posts
+	}
+	items := make([]fiber.Map, len(posts))
+	for i, post := range posts {
+		item := fiber.Map{}
+		for _, name := range f.fields {
+			switch name {
+			case "id":
+				item[name] = post.ID
+			case "title":
+				item[name] = post.Title
+			case "content":
+				item[name] = post.Content
+			case "author":
+				item[name] = post.Author
+			case "user_id":
+				item[name] = post.UserID
+			case "published":
+				item[name] = post.Published
+			case "view_count":
+				item[name] = post.ViewCount
+			case "comment_count":
+				item[name] = post.CommentCount
+			case "created_at":
+				item[name] = post.CreatedAt
+			case "updated_at":
+				item[name] = post.UpdatedAt
+			case "tags":
+				if f.public {
+					item[name] = tagNames(post.Tags)
+				} else {
+					item[name] = post.Tags
+				}
+			}
+		}
+		items[i] = item
+	}
+	return items
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParsePostFiltersSort(t *testing.T) {
+	for _, tc := range []struct {
+		sort    string
+		wantErr string
+	}{
+		{"-created_at", ""},
+		{"updated_at", ""},
+		{"title", ""},
+		{"-popularity", ""},
+		{"comments", ""},
+		{"-comment_count", ""},
+		{"id", "use one of"},
+	} {
+		server := fiber.New()
+		var err error
+		server.Get("/", func(c *fiber.Ctx) error {
+			_, err = parsePostFilters(c, 1)
+			return nil
+		})
+		if _, testErr := server.Test(httptest.NewRequest(http.MethodGet, "/?sort="+tc.sort, nil), -1); testErr != nil {
+			t.Fatal(testErr)
+		}
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("sort=%s: %v", tc.sort, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("sort=%s: error %v, want one mentioning %q", tc.sort, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"blog-app-backend/models"
+)
+
+const (
+	maxTagsPerPost = 10
+	maxTagLength   = 32
+)
+
+// normalizeTags lower-cases and de-duplicates tag names and joins the words
+// of each with dashes: " Web  Dev " → "web-dev"
+func normalizeTags(raw []string) ([]string, error) {
+	seen := map[string]bool{}
+	var tags []string
+	for _, name := range raw {
+		name = strings.Join(strings.Fields(strings.ToLower(name)), "-")
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(name) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d bytes", name, maxTagLength)
+		}
+		for _, r := range name {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) && r != '-' && r != '_' {
+				return nil, fmt.Errorf("tag %q may only contain letters, digits, - and _", name)
+			}
+		}
+		seen[name] = true
+		tags = append(tags, name)
+	}
+	if len(tags) > maxTagsPerPost {
+		return nil, fmt.Errorf("a post can have at most %d tags", maxTagsPerPost)
+	}
+	return tags, nil
+}
+
+// findOrCreateTags returns the tags with the given normalized names,
+// creating the ones that don't exist yet
+func findOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tag := models.Tag{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
@@ -1,75 +1,219 @@
 package handlers
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
-	"blog-app-backend/config"
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
 	"blog-app-backend/models"
+	"blog-app-backend/pagination"
 	"blog-app-backend/services"
 )
 
+// ListPostsResponse is a page of posts. Page is only set when paging by
+// offset; next_cursor and prev_cursor are set in both modes whenever there
+// is a page that way.
 type ListPostsResponse struct {
-	Items    []models.Post `json:"items"`
-	Total    int64         `json:"total"`
-	Page     int           `json:"page"`
-	PageSize int           `json:"page_size"`
+	// Items are models.Post, or with ?fields= maps of the chosen fields
+	Items any   `json:"items"`
+	Total int64 `json:"total"`
+	// TotalEstimated is set when Total is a recent count rather than an
+	// exact one, see ListPublicPosts
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+	Page           int    `json:"page,omitempty"`
+	PageSize       int    `json:"page_size"`
+	NextCursor     string `json:"next_cursor,omitempty"`
+	PrevCursor     string `json:"prev_cursor,omitempty"`
 }
 
 type CreatePostRequest struct {
 	Title   string `json:"title" validate:"required,min=3,max=200"`
 	Content string `json:"content" validate:"required"`
 	Author  string `json:"author" validate:"required"`
+	// Tags are optional; see normalizeTags
+	Tags []string `json:"tags"`
+	// Published defaults to true; false keeps the post as a draft that
+	// only its author can list
+	Published *bool `json:"published"`
 }
 
 var postValidator = validator.New()
 
+// PostsHandler serves the blog post endpoints
+type PostsHandler struct {
+	db        *gorm.DB
+	moderator services.Moderator
+	cursors   *pagination.Signer
+	counts    *pagination.CountCache
+}
+
+func NewPostsHandler(a *app.App) *PostsHandler {
+	return &PostsHandler{
+		db:        a.DB,
+		moderator: a.Moderator,
+		cursors:   a.Cursors,
+		counts:    pagination.NewCountCache(a.Config.Pagination.CountCacheTTL),
+	}
+}
+
 // ListPublicPosts → GET /posts
-func ListPublicPosts(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	if page < 1 {
-		page = 1
+//
+// Pages by offset with ?page=, or by keyset with ?cursor=: pass an empty
+// cursor for the first page, then next_cursor or prev_cursor. Cursor pages
+// do not shift when posts are published in between, cost the same at any
+// depth, and come with an estimated total unless ?total=exact. Sorting,
+// filters and field selection are described at postFilters.
+func (h *PostsHandler) ListPublicPosts(c *fiber.Ctx) error {
+	filters, err := parsePostFilters(c, middleware.CurrentUserID(c))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
+	return h.listPosts(c, filters)
+}
+
+// listPosts serves a page of the posts the filters select
+func (h *PostsHandler) listPosts(c *fiber.Ctx, filters postFilters) error {
 	pageSize, _ := strconv.Atoi(c.Query("page_size", "10"))
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 10
 	}
-	q := strings.TrimSpace(c.Query("q", ""))
 
-	db := config.DB.Model(&models.Post{}).Where("published = ?", true)
+	db := filters.apply(h.db.WithContext(c.UserContext()).Model(&models.Post{})).Session(&gorm.Session{})
 
-	if q != "" {
-		like := "%" + q + "%"
-		db = db.Where("title LIKE ? OR content LIKE ?", like, like)
+	if c.Context().QueryArgs().Has("cursor") {
+		return h.listPostsByCursor(c, db, filters, pageSize)
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
 	}
 
 	var total int64
 	if err := db.Count(&total).Error; err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
 	}
+	h.counts.Set(filters.key(), total)
 
-	var posts []models.Post
 	offset := (page - 1) * pageSize
-	if err := db.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&posts).Error; err != nil {
+	posts, err := h.findPosts(db.Offset(offset), filters, nil, pageSize)
+	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
 	}
 
-	return c.Status(http.StatusOK).JSON(ListPostsResponse{
-		Items:    posts,
+	resp := ListPostsResponse{
+		Items:    filters.project(posts),
 		Total:    total,
 		Page:     page,
 		PageSize: pageSize,
-	})
+	}
+	if len(posts) > 0 {
+		if int64(offset+len(posts)) < total {
+			resp.NextCursor = h.postCursor(posts[len(posts)-1], false, filters)
+		}
+		if page > 1 {
+			resp.PrevCursor = h.postCursor(posts[0], true, filters)
+		}
+	}
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+func (h *PostsHandler) listPostsByCursor(c *fiber.Ctx, db *gorm.DB, filters postFilters, pageSize int) error {
+	// 1) where to start; no cursor is the first page
+	var cur *pagination.Cursor
+	if token := c.Query("cursor"); token != "" {
+		decoded, err := h.cursors.Decode(token, filters.key())
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+		cur = &decoded
+	}
+
+	// 2) one row more than asked tells whether the list goes on
+	posts, err := h.findPosts(db, filters, cur, pageSize+1)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	more := len(posts) > pageSize
+	if more {
+		posts = posts[:pageSize]
+	}
+
+	backwards := cur != nil && cur.Before
+	if backwards && !more {
+		// reached the start: serve the whole first page rather than the
+		// few rows left before the cursor
+		cur, backwards = nil, false
+		if posts, err = h.findPosts(db, filters, nil, pageSize+1); err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+		more = len(posts) > pageSize
+		if more {
+			posts = posts[:pageSize]
+		}
+	}
+	if backwards {
+		slices.Reverse(posts)
+	}
+
+	// 3) the total is exact only on request
+	resp := ListPostsResponse{Items: filters.project(posts), PageSize: pageSize}
+	count := func() (int64, error) {
+		var total int64
+		err := db.Count(&total).Error
+		return total, err
+	}
+	if c.Query("total") == "exact" {
+		resp.Total, err = count()
+		h.counts.Set(filters.key(), resp.Total)
+	} else {
+		resp.Total, err = h.counts.Get(filters.key(), count)
+		resp.TotalEstimated = true
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	// 4) cursors from the first and last rows shown
+	if len(posts) > 0 {
+		if backwards || more {
+			resp.NextCursor = h.postCursor(posts[len(posts)-1], false, filters)
+		}
+		if cur != nil && (!backwards || more) {
+			resp.PrevCursor = h.postCursor(posts[0], true, filters)
+		}
+	}
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// findPosts loads up to limit posts from cur on, with only the columns
+// and associations the filters ask for
+func (h *PostsHandler) findPosts(db *gorm.DB, filters postFilters, cur *pagination.Cursor, limit int) ([]models.Post, error) {
+	if columns := filters.columns(); columns != nil {
+		db = db.Select(columns)
+	}
+	if filters.wantsTags() {
+		db = db.Preload("Tags")
+	}
+	var posts []models.Post
+	err := db.Scopes(pagination.Keyset(filters.order, cur)).Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+func (h *PostsHandler) postCursor(post models.Post, before bool, filters postFilters) string {
+	value := postSorts[filters.order.Column](post)
+	return h.cursors.Encode(pagination.Cursor{Value: value, ID: post.ID, Before: before}, filters.key())
 }
 
 // CreatePost → POST /posts
-func CreatePost(c *fiber.Ctx) error {
+func (h *PostsHandler) CreatePost(c *fiber.Ctx) error {
 	var req CreatePostRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
@@ -78,10 +222,13 @@ func CreatePost(c *fiber.Ctx) error {
 	if err := postValidator.Struct(req); err != nil {
 		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
 	}
+	tagNames, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// Check content for inappropriate language using AI
-	contentFilter := services.NewContentFilterService()
-	isClean, err := contentFilter.CheckContent(req.Title, req.Content)
+	isClean, err := h.moderator.CheckContent(c.UserContext(), req.Title, req.Content)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Content filtering service unavailable. Please try again later."})
 	}
@@ -90,16 +237,122 @@ func CreatePost(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Your post contains inappropriate content or offensive language. Please review and modify your content before posting."})
 	}
 
+	userID := middleware.CurrentUserID(c)
 	post := models.Post{
 		Title:     req.Title,
 		Content:   req.Content,
 		Author:    req.Author,
-		Published: true,
+		UserID:    &userID,
+		Published: req.Published == nil || *req.Published,
 	}
 
-	if err := config.DB.Create(&post).Error; err != nil {
+	err = h.db.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if post.Tags, err = findOrCreateTags(tx, tagNames); err != nil {
+			return err
+		}
+		return tx.Create(&post).Error
+	})
+	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not create post"})
 	}
 
 	return c.Status(http.StatusCreated).JSON(post)
 }
+
+// UpdatePost → PUT /posts/:id
+func (h *PostsHandler) UpdatePost(c *fiber.Ctx) error {
+	// 1) only the author or an admin may change a post
+	post, ok := h.findOwnPost(c)
+	if !ok {
+		return nil
+	}
+
+	// 2) parse and validate the new version
+	var req CreatePostRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON"})
+	}
+	if err := postValidator.Struct(req); err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	tagNames, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// 3) an edit is moderated like a new post
+	isClean, err := h.moderator.CheckContent(c.UserContext(), req.Title, req.Content)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Content filtering service unavailable. Please try again later."})
+	}
+	if !isClean {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Your post contains inappropriate content or offensive language. Please review and modify your content before posting."})
+	}
+
+	// 4) save, replacing the tags
+	err = h.db.WithContext(c.UserContext()).Transaction(func(tx *gorm.DB) error {
+		tags, err := findOrCreateTags(tx, tagNames)
+		if err != nil {
+			return err
+		}
+		changes := map[string]interface{}{
+			"title":   req.Title,
+			"content": req.Content,
+			"author":  req.Author,
+		}
+		if req.Published != nil {
+			changes["published"] = *req.Published
+		}
+		if err := tx.Model(&post).Updates(changes).Error; err != nil {
+			return err
+		}
+		return tx.Model(&post).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not update post"})
+	}
+
+	return c.Status(http.StatusOK).JSON(post)
+}
+
+// DeletePost → DELETE /posts/:id
+func (h *PostsHandler) DeletePost(c *fiber.Ctx) error {
+	post, ok := h.findOwnPost(c)
+	if !ok {
+		return nil
+	}
+
+	if err := h.db.WithContext(c.UserContext()).Delete(&post).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete post"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "post deleted"})
+}
+
+// findOwnPost loads the post in the :id parameter if the current user wrote
+// it or is an admin. When it returns false the error response has been sent.
+func (h *PostsHandler) findOwnPost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid post id"})
+		return post, false
+	}
+
+	if err := h.db.WithContext(c.UserContext()).First(&post, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "post not found"})
+		} else {
+			c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		}
+		return post, false
+	}
+
+	owner := post.UserID != nil && *post.UserID == middleware.CurrentUserID(c)
+	if !owner && middleware.CurrentRole(c) != models.RoleAdmin {
+		c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "you can only change your own posts"})
+		return post, false
+	}
+	return post, true
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+)
+
+// PublicPost is a published post as anyone may see it: without its owner,
+// status or deletion details
+type PublicPost struct {
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	Author       string    `json:"author"`
+	Tags         []string  `json:"tags"`
+	ViewCount    int64     `json:"view_count"`
+	CommentCount int64     `json:"comment_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PublicAuthor is an author name with their published posts
+type PublicAuthor struct {
+	Name       string    `json:"name"`
+	Posts      int64     `json:"posts"`
+	LastPostAt time.Time `json:"last_post_at"`
+}
+
+// PublicTag is a tag with its published posts
+type PublicTag struct {
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+// authorRow is PublicAuthor as it is read from the database
+type authorRow struct {
+	Name       string
+	Posts      int64
+	LastPostAt config.AggregateTime
+}
+
+func (r authorRow) public() PublicAuthor {
+	return PublicAuthor{Name: r.Name, Posts: r.Posts, LastPostAt: r.LastPostAt.Time}
+}
+
+type PublicAuthorsResponse struct {
+	Items    []PublicAuthor `json:"items"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+type PublicTagsResponse struct {
+	Items    []PublicTag `json:"items"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+func publicPosts(posts []models.Post) []PublicPost {
+	out := make([]PublicPost, len(posts))
+	for i, post := range posts {
+		out[i] = publicPost(post)
+	}
+	return out
+}
+
+func publicPost(post models.Post) PublicPost {
+	return PublicPost{
+		ID:           post.ID,
+		Title:        post.Title,
+		Content:      post.Content,
+		Author:       post.Author,
+		Tags:         tagNames(post.Tags),
+		ViewCount:    post.ViewCount,
+		CommentCount: post.CommentCount,
+		CreatedAt:    post.CreatedAt,
+		UpdatedAt:    post.UpdatedAt,
+	}
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// PublicHandler serves the read-only API for anonymous readers
+type PublicHandler struct {
+	db    *gorm.DB
+	posts *PostsHandler
+}
+
+func NewPublicHandler(a *app.App) *PublicHandler {
+	return &PublicHandler{db: a.DB, posts: NewPostsHandler(a)}
+}
+
+// ListPosts → GET /public/posts
+//
+// The same listing as GET /posts, limited to published posts
+func (h *PublicHandler) ListPosts(c *fiber.Ctx) error {
+	filters, err := parsePublicPostFilters(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.posts.listPosts(c, filters)
+}
+
+// GetPost → GET /public/posts/:id
+//
+// Each read that reaches the server counts as a view, which is what
+// ?sort=popularity orders by
+func (h *PublicHandler) GetPost(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "post not found"})
+	}
+
+	var post models.Post
+	err = h.db.WithContext(c.UserContext()).Preload("Tags").
+		Where("published = ?", true).First(&post, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "post not found"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	// a lost view is not worth failing the read for
+	if err := countView(h.db.WithContext(c.UserContext()), post.ID); err != nil {
+		middleware.Logger(c).Warn("could not count a post view", "post_id", post.ID, "error", err)
+	} else {
+		post.ViewCount++
+	}
+
+	return c.Status(http.StatusOK).JSON(publicPost(post))
+}
+
+// ListAuthors → GET /public/authors
+func (h *PublicHandler) ListAuthors(c *fiber.Ctx) error {
+	page, pageSize := publicPage(c)
+	db := h.db.WithContext(c.UserContext()).Model(&models.Post{}).Where("published = ?", true)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Distinct("author").Count(&total).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	var rows []authorRow
+	err := db.Select("author AS name, COUNT(*) AS posts, MAX(created_at) AS last_post_at").
+		Group("author").Order("author").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Scan(&rows).Error
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	authors := make([]PublicAuthor, len(rows))
+	for i, row := range rows {
+		authors[i] = row.public()
+	}
+
+	return c.Status(http.StatusOK).JSON(PublicAuthorsResponse{
+		Items:    authors,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// GetAuthor → GET /public/authors/:author
+//
+// Their posts are at /public/posts?author=
+func (h *PublicHandler) GetAuthor(c *fiber.Ctx) error {
+	name, _ := url.PathUnescape(c.Params("author"))
+
+	var rows []authorRow
+	err := h.db.WithContext(c.UserContext()).Model(&models.Post{}).
+		Where("published = ? AND author = ?", true, name).
+		Select("author AS name, COUNT(*) AS posts, MAX(created_at) AS last_post_at").
+		Group("author").
+		Scan(&rows).Error
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if len(rows) == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "author not found"})
+	}
+
+	return c.Status(http.StatusOK).JSON(rows[0].public())
+}
+
+// ListTags → GET /public/tags
+//
+// Tags of published posts, most used first
+func (h *PublicHandler) ListTags(c *fiber.Ctx) error {
+	page, pageSize := publicPage(c)
+	db := h.db.WithContext(c.UserContext()).Table("tags").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.published = ? AND posts.deleted_at IS NULL", true)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Distinct("tags.id").Count(&total).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	tags := []PublicTag{}
+	err := db.Select("tags.name AS name, COUNT(*) AS posts").
+		Group("tags.name").Order("posts DESC").Order("tags.name").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Scan(&tags).Error
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	return c.Status(http.StatusOK).JSON(PublicTagsResponse{
+		Items:    tags,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+func publicPage(c *fiber.Ctx) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ = strconv.Atoi(c.Query("page_size", "50"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+	return page, pageSize
+}
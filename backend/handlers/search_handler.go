@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/app"
+	"blog-app-backend/search"
+)
+
+// maxQueryLength bounds the work a single search can cause
+const maxQueryLength = 200
+
+type SearchResult struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	UserID    *uint     `json:"user_id,omitempty"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	Score     float64   `json:"score"`
+	// TitleHTML and Snippet are escaped HTML with the matches in <mark>
+	TitleHTML string `json:"title_html"`
+	Snippet   string `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Query string `json:"query"`
+	// DidYouMean is a spelling correction of a query that found nothing;
+	// Items are then the results of the corrected query
+	DidYouMean string         `json:"did_you_mean,omitempty"`
+	Items      []SearchResult `json:"items"`
+	Total      int            `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+}
+
+// SearchHandler serves full-text search over the published posts
+type SearchHandler struct {
+	index *search.Index
+}
+
+func NewSearchHandler(a *app.App) *SearchHandler {
+	return &SearchHandler{index: a.Search}
+}
+
+// Search → GET /search?q=
+//
+// Words are ranked by relevance, "quoted phrases" must appear as written and
+// -word or -"phrase" leaves out posts containing them.
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	// 1) parse the query and paging
+	raw := strings.TrimSpace(c.Query("q"))
+	if len(raw) > maxQueryLength {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "query is too long"})
+	}
+	query, err := search.ParseQuery(raw)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "q must contain at least one word to search for"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size", "10"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	// 2) rank, page and highlight
+	hits, total := h.index.Search(query, (page-1)*pageSize, pageSize)
+
+	// 3) nothing found: retry with the misspelled words corrected
+	var didYouMean string
+	if total == 0 {
+		if corrected, ok := h.index.DidYouMean(raw); ok {
+			if query, err := search.ParseQuery(corrected); err == nil {
+				didYouMean = corrected
+				hits, total = h.index.Search(query, (page-1)*pageSize, pageSize)
+			}
+		}
+	}
+
+	items := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		items[i] = SearchResult{
+			ID:        hit.ID,
+			Title:     hit.Title,
+			Author:    hit.Author,
+			UserID:    hit.UserID,
+			Tags:      hit.Tags,
+			CreatedAt: hit.CreatedAt,
+			Score:     hit.Score,
+			TitleHTML: hit.TitleHTML,
+			Snippet:   hit.Snippet,
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(SearchResponse{
+		Query:      raw,
+		DidYouMean: didYouMean,
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+type SuggestResponse struct {
+	Prefix string              `json:"prefix"`
+	Items  []search.Suggestion `json:"items"`
+	// DidYouMean corrects a misspelled prefix that completes nothing
+	DidYouMean string `json:"did_you_mean,omitempty"`
+}
+
+// Suggest → GET /search/suggest?prefix=
+func (h *SearchHandler) Suggest(c *fiber.Ctx) error {
+	prefix := strings.TrimLeft(c.Query("prefix"), " ")
+	if len(prefix) > maxQueryLength {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "prefix is too long"})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "8"))
+	if limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	resp := SuggestResponse{Prefix: prefix, Items: []search.Suggestion{}}
+	if strings.TrimSpace(prefix) == "" {
+		return c.Status(http.StatusOK).JSON(resp)
+	}
+
+	resp.Items = h.index.Suggest(prefix, limit)
+	if len(resp.Items) == 0 {
+		if corrected, ok := h.index.DidYouMean(prefix); ok {
+			resp.DidYouMean = corrected
+		}
+	}
+	return c.Status(http.StatusOK).JSON(resp)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"blog-app-backend/app"
+	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+)
+
+// SessionsHandler lets users see and revoke the devices they are logged in on
+type SessionsHandler struct {
+	db *gorm.DB
+}
+
+func NewSessionsHandler(a *app.App) *SessionsHandler {
+	return &SessionsHandler{db: a.DB}
+}
+
+// ListSessions → GET /sessions
+func (h *SessionsHandler) ListSessions(c *fiber.Ctx) error {
+	userID := middleware.CurrentUserID(c)
+
+	var sessions []models.Session
+	if err := h.db.WithContext(c.UserContext()).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+
+	currentID := middleware.CurrentSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"items": sessions})
+}
+
+// RevokeSession → DELETE /sessions/:id
+func (h *SessionsHandler) RevokeSession(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid session id"})
+	}
+
+	result := h.db.WithContext(c.UserContext()).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, middleware.CurrentUserID(c)).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "session not found"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "session revoked"})
+}
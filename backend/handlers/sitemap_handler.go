@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/models"
+	"blog-app-backend/sitemap"
+)
+
+// SitemapHandler serves robots.txt and, when the backend serves pages, the
+// sitemaps of the published posts and of the home, archive, tag and author
+// pages. Without pages there is nothing to list: the frontend has no page
+// per post.
+type SitemapHandler struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache *sitemap.Cache
+}
+
+func NewSitemapHandler(a *app.App) *SitemapHandler {
+	return &SitemapHandler{db: a.DB, cfg: a.Config, cache: a.Sitemaps}
+}
+
+// Robots → GET /robots.txt
+func (h *SitemapHandler) Robots(c *fiber.Ctx) error {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(h.cfg.Robots.Disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	for _, path := range h.cfg.Robots.Disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	if h.cfg.Pages.Enabled {
+		fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", pagesBaseURL(h.cfg))
+	}
+	if extra := strings.TrimSpace(h.cfg.Robots.Extra); extra != "" {
+		fmt.Fprintf(&b, "\n%s\n", extra)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.Status(http.StatusOK).SendString(b.String())
+}
+
+// Index → GET /sitemap.xml
+//
+// A sitemap index of as many pages-N.xml and posts-N.xml as it takes to
+// list every page and post within the sitemap size limit
+func (h *SitemapHandler) Index(c *fiber.Ctx) error {
+	base := pagesBaseURL(h.cfg)
+	return h.send(c, "index", func() ([]byte, error) {
+		db := h.readDB(c)
+
+		pages, err := h.pageURLs(db, base)
+		if err != nil {
+			return nil, err
+		}
+		var sitemaps []sitemap.URL
+		for n := 1; (n-1)*sitemap.MaxURLs < len(pages); n++ {
+			var lastMod time.Time
+			for _, page := range pages[(n-1)*sitemap.MaxURLs : min(n*sitemap.MaxURLs, len(pages))] {
+				if page.LastMod.After(lastMod) {
+					lastMod = page.LastMod
+				}
+			}
+			sitemaps = append(sitemaps, sitemap.URL{
+				Loc:     fmt.Sprintf("%s/sitemaps/pages-%d.xml", base, n),
+				LastMod: lastMod,
+			})
+		}
+
+		var total int64
+		if err := h.published(db).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		for n := 1; n == 1 || int64(n-1)*sitemap.MaxURLs < total; n++ {
+			// the newest change among the posts of the file
+			chunk := h.published(db).Select("updated_at").Order("id").
+				Limit(sitemap.MaxURLs).Offset((n - 1) * sitemap.MaxURLs)
+			var lastMod config.AggregateTime
+			if err := db.Table("(?) AS chunk", chunk).Select("MAX(updated_at)").Scan(&lastMod).Error; err != nil {
+				return nil, err
+			}
+			sitemaps = append(sitemaps, sitemap.URL{
+				Loc:     fmt.Sprintf("%s/sitemaps/posts-%d.xml", base, n),
+				LastMod: lastMod.Time,
+			})
+		}
+		return sitemap.Index(sitemaps)
+	})
+}
+
+// Sitemap → GET /sitemaps/:name
+//
+// pages-N.xml lists the Nth run of the home, archive, tag and author
+// pages; posts-N.xml lists the Nth run of posts, oldest first
+func (h *SitemapHandler) Sitemap(c *fiber.Ctx) error {
+	// the name outlives the request as a cache key, so it must not point
+	// into fiber's reused buffers
+	name := utils.CopyString(c.Params("name"))
+	base := pagesBaseURL(h.cfg)
+
+	kind, n, ok := parseSitemapName(name)
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "sitemap not found"})
+	}
+	return h.send(c, name, func() ([]byte, error) {
+		db := h.readDB(c)
+		if kind == "pages" {
+			pages, err := h.pageURLs(db, base)
+			if err != nil || (n-1)*sitemap.MaxURLs >= len(pages) {
+				return nil, err
+			}
+			return sitemap.URLSet(pages[(n-1)*sitemap.MaxURLs : min(n*sitemap.MaxURLs, len(pages))])
+		}
+
+		var posts []models.Post
+		err := h.published(db).Select("id", "updated_at").Order("id").
+			Limit(sitemap.MaxURLs).Offset((n - 1) * sitemap.MaxURLs).
+			Find(&posts).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(posts) == 0 && n > 1 {
+			return nil, nil
+		}
+		urls := make([]sitemap.URL, len(posts))
+		for i, post := range posts {
+			urls[i] = sitemap.URL{Loc: postURL(h.cfg, post.ID), LastMod: post.UpdatedAt}
+		}
+		return sitemap.URLSet(urls)
+	})
+}
+
+// parseSitemapName splits pages-N.xml and posts-N.xml into their kind and N
+func parseSitemapName(name string) (kind string, n int, ok bool) {
+	raw, isXML := strings.CutSuffix(name, ".xml")
+	kind, raw, found := strings.Cut(raw, "-")
+	if !isXML || !found || (kind != "pages" && kind != "posts") {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+	return kind, n, true
+}
+
+// readDB is where sitemaps are built from: the primary, not a replica. A
+// file is built right after a write drops the cache, and one built from a
+// replica that has not caught up yet would be kept until the next write.
+func (h *SitemapHandler) readDB(c *fiber.Ctx) *gorm.DB {
+	// a new session, so that each query built on it starts afresh
+	return h.db.WithContext(c.UserContext()).Clauses(dbresolver.Write).Session(&gorm.Session{})
+}
+
+// pageURLs are the home and archive pages, then every tag and author page,
+// each changing with the newest change to its posts
+func (h *SitemapHandler) pageURLs(db *gorm.DB, base string) ([]sitemap.URL, error) {
+	var lastMod config.AggregateTime
+	if err := h.published(db).Select("MAX(updated_at)").Scan(&lastMod).Error; err != nil {
+		return nil, err
+	}
+	urls := []sitemap.URL{
+		{Loc: base + "/", LastMod: lastMod.Time},
+		{Loc: base + "/archive", LastMod: lastMod.Time},
+	}
+
+	var tags []struct {
+		Name    string
+		LastMod config.AggregateTime
+	}
+	err := db.Table("tags").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.published = ? AND posts.deleted_at IS NULL", true).
+		Select("tags.name AS name, MAX(posts.updated_at) AS last_mod").
+		Group("tags.name").Order("tags.name").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		urls = append(urls, sitemap.URL{Loc: base + "/tags/" + url.PathEscape(tag.Name), LastMod: tag.LastMod.Time})
+	}
+
+	var authors []struct {
+		Name    string
+		LastMod config.AggregateTime
+	}
+	err = h.published(db).Select("author AS name, MAX(updated_at) AS last_mod").
+		Group("author").Order("author").
+		Scan(&authors).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, author := range authors {
+		urls = append(urls, sitemap.URL{Loc: base + "/authors/" + url.PathEscape(author.Name), LastMod: author.LastMod.Time})
+	}
+	return urls, nil
+}
+
+func (h *SitemapHandler) published(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Post{}).Where("published = ?", true)
+}
+
+// send serves the cached file under key, building it if need be; a build
+// that returns nothing is a 404. The key is the file's name: nothing else
+// about the request, such as its Host, goes into a sitemap.
+func (h *SitemapHandler) send(c *fiber.Ctx, key string, build func() ([]byte, error)) error {
+	file, err := h.cache.Get(key, build)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not build sitemap"})
+	}
+	if file == nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "sitemap not found"})
+	}
+	c.Set(fiber.HeaderContentType, sitemap.ContentType)
+	return c.Status(http.StatusOK).Send(file)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/config"
+	"blog-app-backend/sitemap"
+)
+
+func TestSitemapsSplitPages(t *testing.T) {
+	a := newTestApp(t, func(cfg *config.Config) {
+		cfg.Pages.Enabled = true
+		cfg.Pages.BaseURL = "https://blog.example.com"
+	})
+	server := fiber.New()
+	h := NewSitemapHandler(a)
+	server.Get("/sitemap.xml", h.Index)
+	server.Get("/sitemaps/:name", h.Sitemap)
+
+	// one author page per post: with home and archive, one URL too many
+	// for a single file
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	err := a.DB.Exec(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?)
+		INSERT INTO posts (title, content, author, published, created_at, updated_at)
+		SELECT 'post', '', 'author-' || i, true, ?, ? FROM n`, sitemap.MaxURLs-1, now, now).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) (int, []byte) {
+		resp, err := server.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+	count := func(body []byte) int {
+		var set struct {
+			URLs []struct {
+				Loc string `xml:"loc"`
+			} `xml:"url"`
+		}
+		if err := xml.Unmarshal(body, &set); err != nil {
+			t.Fatal(err)
+		}
+		return len(set.URLs)
+	}
+
+	_, index := get("/sitemap.xml")
+	for _, want := range []string{"/sitemaps/pages-1.xml", "/sitemaps/pages-2.xml", "/sitemaps/posts-1.xml"} {
+		if !strings.Contains(string(index), "https://blog.example.com"+want) {
+			t.Errorf("the index does not list %s", want)
+		}
+	}
+	if strings.Contains(string(index), "pages-3.xml") || strings.Contains(string(index), "posts-2.xml") {
+		t.Error("the index lists an empty sitemap")
+	}
+
+	if status, body := get("/sitemaps/pages-1.xml"); status != http.StatusOK || count(body) != sitemap.MaxURLs {
+		t.Errorf("pages-1.xml: status %d, %d URLs; want a full file", status, count(body))
+	}
+	if status, body := get("/sitemaps/pages-2.xml"); status != http.StatusOK || count(body) != 1 {
+		t.Errorf("pages-2.xml: status %d, %d URLs; want the one left over", status, count(body))
+	}
+	for _, name := range []string{"pages-3.xml", "pages.xml", "posts-0.xml", "tags-1.xml"} {
+		if status, _ := get("/sitemaps/" + name); status != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", name, status)
+		}
+	}
+}
+
+func TestRobotsNamesSitemapOnlyWithPages(t *testing.T) {
+	for _, pages := range []bool{false, true} {
+		a := newTestApp(t, func(cfg *config.Config) {
+			cfg.Pages.Enabled = pages
+			cfg.Pages.BaseURL = "https://blog.example.com"
+		})
+		server := fiber.New()
+		server.Get("/robots.txt", NewSitemapHandler(a).Robots)
+		resp, err := server.Test(httptest.NewRequest(http.MethodGet, "/robots.txt", nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if has := strings.Contains(string(body), "Sitemap: https://blog.example.com/sitemap.xml"); has != pages {
+			t.Errorf("pages %v: robots.txt names the sitemap: %v\n%s", pages, has, body)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package logging builds the structured logger used across the backend.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+
+	"blog-app-backend/config"
+)
+
+// Redacted replaces the value of attributes that must never be logged
+const Redacted = "[REDACTED]"
+
+// secretKeys are attribute keys whose values are dropped entirely
+var secretKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"authorization": true,
+	"cookie":        true,
+	"api_key":       true,
+}
+
+// emailKeys are attribute keys holding email addresses, which are masked
+var emailKeys = map[string]bool{
+	"email": true,
+	"to":    true,
+}
+
+// New returns a logger writing JSON (or text) lines to w at the configured
+// level, with secrets and personal data redacted
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(cfg.Level),
+		ReplaceAttr: redact,
+	}
+
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// ParseLevel maps debug, info, warn and error to slog levels; anything
+// else is info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case secretKeys[key]:
+		return slog.String(a.Key, Redacted)
+	case emailKeys[key] && a.Value.Kind() == slog.KindString:
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	}
+	return a
+}
+
+// MaskEmail keeps the first letter and the domain of an address, enough to
+// tell users apart while debugging: jane@example.com → j***@example.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return Redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
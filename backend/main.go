@@ -1,57 +1,254 @@
 package main
 
 import (
+	"blog-app-backend/app"
 	"blog-app-backend/config"
-	"blog-app-backend/models"
+	"blog-app-backend/export"
+	"blog-app-backend/logging"
+	"blog-app-backend/migrations"
 	"blog-app-backend/routes"
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
+	"blog-app-backend/services"
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 func main() {
-	// Connect to database
-	config.ConnectDB()
-
-	// Auto-migrate the schema
-	err := config.DB.AutoMigrate(
-		&models.Post{},
-		&models.User{},
-	)
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
-	}
-
-	// Initialize Fiber app
-	app := fiber.New()
-
-	// Enable CORS for frontend communication
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:3000",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
-		AllowCredentials: true,
-	}))
-
-	// API routes
-	app.Get("/", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"message": "Blog App API is running!",
-			"status":  "success",
-		})
-	})
-
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":   "healthy",
-			"database": "connected",
-		})
-	})
-
-	// Register API routes
-	routes.Register(app)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flag.Parse()
+
+	// `config dump` prints the effective configuration with secrets redacted
+	if flag.Arg(0) == "config" && flag.Arg(1) == "dump" {
+		dumpConfig(*configFile)
+		return
+	}
+
+	// `migrate up|down|status|create` manages the database schema
+	if flag.Arg(0) == "migrate" {
+		runMigrate(*configFile, flag.Args()[1:])
+		return
+	}
+
+	// `export-static --out dir` renders the public site to static files
+	if flag.Arg(0) == "export-static" {
+		// returns, rather than exits, so that the app is closed first
+		if err := runExportStatic(*configFile, flag.Args()[1:]); err != nil {
+			fatal("export failed", err)
+		}
+		return
+	}
+
+	// Load and validate configuration
+	cfg := config.MustLoad(*configFile)
+
+	// Structured logs from here on, including anything written with the log package
+	logger := logging.New(cfg.Log, os.Stderr)
+	slog.SetDefault(logger)
+
+	// Wire up the database, keys and services
+	a, err := app.New(cfg, app.WithLogger(logger))
+	if err != nil {
+		fatal("failed to start", err)
+	}
+
+	// Refuse to serve against an outdated schema
+	if err := migrations.New(a.DB, a.Logger).Check(); err != nil {
+		fatal("database not ready", err)
+	}
+
+	// Purge accounts whose deletion grace period has ended
+	stopPurger := services.NewAccountPurger(a.DB, a.Logger).Start(time.Hour)
+
+	// Build the search index and keep it up to date
+	stopSearch := a.SearchSync.Start()
+
+	// Sample memory usage for /api/memory/history and leak detection
+	stopMemorySampler := a.Memory.Start()
+
+	// Build the fully wired Fiber app, and the diagnostics server if it
+	// has a listener of its own
+	server := routes.New(a)
+	diagnostics := routes.NewDiagnostics(a)
 
 	// Start server
-	log.Println("Server starting on :4000")
-	app.Listen(":4000")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	addr := fmt.Sprintf(":%d", cfg.Server.Port)
+	listenErr := make(chan error, 2)
+	go func() {
+		logger.Info("server starting", "addr", addr)
+		listenErr <- server.Listen(addr)
+	}()
+	if diagnostics != nil {
+		go func() {
+			logger.Info("diagnostics listening", "addr", cfg.Diagnostics.Addr)
+			listenErr <- diagnostics.Listen(cfg.Diagnostics.Addr)
+		}()
+	}
+
+	select {
+	case err := <-listenErr:
+		fatal("server stopped", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// 1) fail readiness so load balancers stop sending new requests
+	logger.Info("shutting down")
+	a.SetReady(false)
+	time.Sleep(cfg.Server.DrainDelay)
+
+	// 2) stop accepting connections and let in-flight requests finish
+	if err := server.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		logger.Error("shutdown", "error", err)
+	}
+	if diagnostics != nil {
+		if err := diagnostics.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+			logger.Error("diagnostics shutdown", "error", err)
+		}
+	}
+
+	// 3) stop background workers, then release the database
+	stopPurger()
+	stopMemorySampler()
+	stopSearch()
+	if err := a.Close(); err != nil {
+		logger.Error("closing database", "error", err)
+	}
+	logger.Info("server stopped")
+}
+
+// fatal logs the error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func dumpConfig(path string) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	if err := cfg.Dump(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runMigrate(path string, args []string) {
+	usage := "usage: migrate up | down [steps] | status | create <name>"
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	// create only writes a file, it doesn't need a database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal("usage: migrate create <name>")
+		}
+		file, err := migrations.Create("migrations", args[1])
+		if err != nil {
+			log.Fatal("Failed to create migration: ", err)
+		}
+		fmt.Println("Created", file)
+		return
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator := migrations.New(db, slog.Default())
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal("steps must be a positive number")
+			}
+		}
+		err = migrator.Down(steps)
+	case "status":
+		var statuses []migrations.Status
+		if statuses, err = migrator.Status(); err == nil {
+			for _, s := range statuses {
+				applied := "pending"
+				if s.AppliedAt != nil {
+					applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, applied)
+			}
+		}
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal("Migration failed: ", err)
+	}
+}
+
+func runExportStatic(path string, args []string) error {
+	flags := flag.NewFlagSet("export-static", flag.ExitOnError)
+	out := flags.String("out", "", "directory to write the site to")
+	baseURL := flags.String("base-url", "", "public URL of the exported site (default pages.base_url)")
+	full := flags.Bool("full", false, "render every post, not just those updated since the last export")
+	flags.Parse(args)
+	if *out == "" || flags.NArg() > 0 {
+		log.Fatal("usage: export-static --out <dir> [--base-url <url>] [--full]")
+	}
+
+	// the export is of the pages, whether or not this instance serves them
+	cfg := config.MustLoad(path)
+	cfg.Pages.Enabled = true
+	if *baseURL != "" {
+		cfg.Pages.BaseURL = *baseURL
+	}
+	// the overrides are checked like the file: pages need a base URL, and
+	// --base-url must be an http(s) URL
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	logger := logging.New(cfg.Log, os.Stderr)
+	slog.SetDefault(logger)
+
+	a, err := app.New(cfg, app.WithLogger(logger))
+	if err != nil {
+		return fmt.Errorf("starting: %w", err)
+	}
+	defer a.Close()
+	if err := migrations.New(a.DB, a.Logger).Check(); err != nil {
+		return fmt.Errorf("database not ready: %w", err)
+	}
+
+	exporter, err := export.New(a, *out)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stats, err := exporter.Run(ctx, *full)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d files to %s: %d written, %d unchanged, %d removed\n",
+		stats.Files, *out, stats.Written, stats.Unchanged, stats.Removed)
+	return nil
 }
@@ -0,0 +1,220 @@
+// Package metrics collects the Prometheus metrics served on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+	"blog-app-backend/monitor"
+)
+
+const namespace = "blog"
+
+// Metrics owns a registry and the application's own metrics. Each App has
+// its own, so instances don't share counters.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	moderationDuration prometheus.Histogram
+	moderationVerdicts *prometheus.CounterVec
+	logins             *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent handling HTTP requests, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		moderationDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "moderation_duration_seconds",
+			Help:      "Latency of content moderation calls.",
+			Buckets:   []float64{.1, .25, .5, 1, 2, 5, 10, 30},
+		}),
+		moderationVerdicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "moderation_verdicts_total",
+			Help:      "Content moderation results: clean, rejected or error.",
+		}, []string{"verdict"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts, by method (password, oidc) and result.",
+		}, []string{"method", "result"}),
+	}
+
+	m.registry.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.moderationDuration,
+		m.moderationVerdicts,
+		m.logins,
+		newRuntimeCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// RegisterDB exports the pool statistics of a database connection, labelled
+// with name (e.g. "primary" or a replica address)
+func (m *Metrics) RegisterDB(name string, db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterReplicas exports the pool statistics and health of every replica
+func (m *Metrics) RegisterReplicas(replicas *config.ReplicaSet) {
+	replicas.Each(func(addr string, db *sql.DB, healthy func() bool) {
+		m.RegisterDB("replica "+addr, db)
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "db_replica_healthy",
+			Help:        "Whether the read replica passed its last health check.",
+			ConstLabels: prometheus.Labels{"replica": addr},
+		}, func() float64 {
+			if healthy() {
+				return 1
+			}
+			return 0
+		}))
+	})
+}
+
+// RegisterMemoryAlerts exports whether each memory growth alert is raised
+func (m *Metrics) RegisterMemoryAlerts(sampler *monitor.MemorySampler) {
+	for _, kind := range []string{monitor.AlertHeapGrowth, monitor.AlertGoroutineGrowth} {
+		kind := kind
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "memory_alert",
+			Help:        "Whether a memory growth alert is raised.",
+			ConstLabels: prometheus.Labels{"kind": kind},
+		}, func() float64 {
+			if sampler.Alerting(kind) {
+				return 1
+			}
+			return 0
+		}))
+	}
+}
+
+// Register adds any other collector to the registry
+func (m *Metrics) Register(c prometheus.Collector) {
+	m.registry.MustRegister(c)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware counts and times every request. Requests that match no route
+// are labelled "unmatched", so scanners can't blow up the label space.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		if fe != nil && fe.Code == fiber.StatusNotFound {
+			route = "unmatched"
+		}
+
+		// fasthttp reuses the method's buffer, so copy it before keeping it as a label
+		method := utils.CopyString(c.Method())
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Login counts a login attempt; result is "success" or the failure reason
+func (m *Metrics) Login(method, result string) {
+	m.logins.WithLabelValues(method, result).Inc()
+}
+
+// runtimeCollector exports middleware.MemoryStats plus the goroutine count,
+// read fresh on every scrape
+type runtimeCollector struct {
+	gauges     map[string]*prometheus.Desc
+	numGC      *prometheus.Desc
+	totalAlloc *prometheus.Desc
+	goroutines *prometheus.Desc
+}
+
+func newRuntimeCollector() *runtimeCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "runtime", name), help, nil, nil)
+	}
+	return &runtimeCollector{
+		gauges: map[string]*prometheus.Desc{
+			"alloc":         desc("alloc_bytes", "Bytes allocated and in use."),
+			"sys":           desc("sys_bytes", "Bytes obtained from the system."),
+			"heap_alloc":    desc("heap_alloc_bytes", "Heap bytes allocated and in use."),
+			"heap_sys":      desc("heap_sys_bytes", "Heap bytes obtained from the system."),
+			"heap_idle":     desc("heap_idle_bytes", "Heap bytes in idle spans."),
+			"heap_inuse":    desc("heap_inuse_bytes", "Heap bytes in non-idle spans."),
+			"heap_released": desc("heap_released_bytes", "Heap bytes released to the OS."),
+		},
+		numGC:      desc("gc_cycles_total", "Completed garbage collection cycles."),
+		totalAlloc: desc("alloc_bytes_total", "Cumulative bytes allocated."),
+		goroutines: desc("goroutines", "Number of goroutines."),
+	}
+}
+
+func (rc *runtimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range rc.gauges {
+		ch <- d
+	}
+	ch <- rc.numGC
+	ch <- rc.totalAlloc
+	ch <- rc.goroutines
+}
+
+func (rc *runtimeCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := middleware.GetMemoryStats()
+	values := map[string]uint64{
+		"alloc":         stats.Alloc,
+		"sys":           stats.Sys,
+		"heap_alloc":    stats.HeapAlloc,
+		"heap_sys":      stats.HeapSys,
+		"heap_idle":     stats.HeapIdle,
+		"heap_inuse":    stats.HeapInuse,
+		"heap_released": stats.HeapReleased,
+	}
+	for name, d := range rc.gauges {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, float64(values[name]))
+	}
+	ch <- prometheus.MustNewConstMetric(rc.numGC, prometheus.CounterValue, float64(stats.NumGC))
+	ch <- prometheus.MustNewConstMetric(rc.totalAlloc, prometheus.CounterValue, float64(stats.TotalAlloc))
+	ch <- prometheus.MustNewConstMetric(rc.goroutines, prometheus.GaugeValue, float64(runtime.NumGoroutine()))
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"blog-app-backend/services"
+)
+
+// instrumentedModerator records the latency and verdict of every check
+type instrumentedModerator struct {
+	services.Moderator
+	metrics *Metrics
+}
+
+// InstrumentModerator wraps a moderator so its calls show up in the metrics
+func (m *Metrics) InstrumentModerator(moderator services.Moderator) services.Moderator {
+	return &instrumentedModerator{Moderator: moderator, metrics: m}
+}
+
+func (im *instrumentedModerator) CheckContent(ctx context.Context, title, content string) (bool, error) {
+	start := time.Now()
+	clean, err := im.Moderator.CheckContent(ctx, title, content)
+	im.metrics.moderationDuration.Observe(time.Since(start).Seconds())
+
+	verdict := "clean"
+	switch {
+	case err != nil:
+		verdict = "error"
+	case !clean:
+		verdict = "rejected"
+	}
+	im.metrics.moderationVerdicts.WithLabelValues(verdict).Inc()
+
+	return clean, err
+}
+
+// Ping keeps the readiness check working through the wrapper. A moderator
+// that can't be pinged has nothing to check.
+func (im *instrumentedModerator) Ping(ctx context.Context) error {
+	if pinger, ok := im.Moderator.(services.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/config"
+)
+
+// IPAllowList only lets through requests from the given addresses or CIDR
+// ranges. Entries must already be validated (config.Validate does that).
+func IPAllowList(entries []string) fiber.Handler {
+	var allowed []*net.IPNet
+	for _, entry := range entries {
+		if ipNet, err := config.ParseIPNet(entry); err == nil {
+			allowed = append(allowed, ipNet)
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		ip := net.ParseIP(c.IP())
+		for _, ipNet := range allowed {
+			if ip != nil && ipNet.Contains(ip) {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+	}
+}
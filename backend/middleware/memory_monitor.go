@@ -1,11 +1,8 @@
 package middleware
 
 import (
-	"log"
 	"runtime"
 	"time"
-
-	"github.com/gofiber/fiber/v2"
 )
 
 // MemoryStats represents memory usage statistics
@@ -44,73 +41,32 @@ func BytesToMB(b uint64) float64 {
 	return float64(b) / 1024 / 1024
 }
 
-// MemoryMonitor middleware logs memory usage for each request
-func MemoryMonitor() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		start := time.Now()
-		startStats := GetMemoryStats()
-
-		// Process request
-		err := c.Next()
-
-		// Log memory usage after request
-		endStats := GetMemoryStats()
-		duration := time.Since(start)
-
-		// Calculate memory delta
-		allocDelta := int64(endStats.Alloc) - int64(startStats.Alloc)
-
-		log.Printf(
-			"[MEMORY] %s %s | Duration: %v | Memory: %.2fMB | Delta: %+.2fMB | GC: %d",
-			c.Method(),
-			c.Path(),
-			duration,
-			BytesToMB(endStats.Alloc),
-			BytesToMB(uint64(allocDelta)),
-			endStats.NumGC-startStats.NumGC,
-		)
-
-		return err
-	}
+// DetailedMemoryStats includes additional system information
+type DetailedMemoryStats struct {
+	MemoryStats
+	Timestamp   time.Time `json:"timestamp"`
+	Goroutines  int       `json:"goroutines"`
+	CPUCount    int       `json:"cpu_count"`
+	GoVersion   string    `json:"go_version"`
+	AllocMB     float64   `json:"alloc_mb"`
+	SysMB       float64   `json:"sys_mb"`
+	HeapAllocMB float64   `json:"heap_alloc_mb"`
+	HeapSysMB   float64   `json:"heap_sys_mb"`
 }
 
-// MemoryMonitorDetailed provides more detailed logging for specific routes
-func MemoryMonitorDetailed(routeName string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		start := time.Now()
-		startStats := GetMemoryStats()
-
-		log.Printf(
-			"[MEMORY-START] %s | Route: %s | Memory: %.2fMB | Heap: %.2fMB",
-			c.Method(),
-			routeName,
-			BytesToMB(startStats.Alloc),
-			BytesToMB(startStats.HeapAlloc),
-		)
-
-		// Process request
-		err := c.Next()
-
-		// Detailed logging after request
-		endStats := GetMemoryStats()
-		duration := time.Since(start)
-
-		allocDelta := int64(endStats.Alloc) - int64(startStats.Alloc)
-		heapDelta := int64(endStats.HeapAlloc) - int64(startStats.HeapAlloc)
-
-		log.Printf(
-			"[MEMORY-END] %s | Route: %s | Duration: %v | Memory: %.2fMB (+%.2fMB) | Heap: %.2fMB (+%.2fMB) | GC: %d | Sys: %.2fMB",
-			c.Method(),
-			routeName,
-			duration,
-			BytesToMB(endStats.Alloc),
-			BytesToMB(uint64(allocDelta)),
-			BytesToMB(endStats.HeapAlloc),
-			BytesToMB(uint64(heapDelta)),
-			endStats.NumGC-startStats.NumGC,
-			BytesToMB(endStats.Sys),
-		)
-
-		return err
+// GetDetailedMemoryStats returns current memory statistics with system information
+func GetDetailedMemoryStats() DetailedMemoryStats {
+	stats := GetMemoryStats()
+
+	return DetailedMemoryStats{
+		MemoryStats: stats,
+		Timestamp:   time.Now(),
+		Goroutines:  runtime.NumGoroutine(),
+		CPUCount:    runtime.NumCPU(),
+		GoVersion:   runtime.Version(),
+		AllocMB:     BytesToMB(stats.Alloc),
+		SysMB:       BytesToMB(stats.Sys),
+		HeapAllocMB: BytesToMB(stats.HeapAlloc),
+		HeapSysMB:   BytesToMB(stats.HeapSys),
 	}
-}
\ No newline at end of file
+}
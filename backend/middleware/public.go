@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimitByIP allows each client address max requests per window and
+// answers the rest with 429 Too Many Requests. The address is c.IP(), so
+// behind a reverse proxy the server must trust it to name the client (see
+// config.ServerConfig.ProxyHeader). Counters are kept in memory, so with
+// several instances each one counts on its own.
+func RateLimitByIP(max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many requests"})
+		},
+	})
+}
+
+// PublicCache lets browsers and shared caches keep successful responses
+// for maxAge, unless the handler chose its own Cache-Control
+func PublicCache(maxAge time.Duration) fiber.Handler {
+	value := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		status := c.Response().StatusCode()
+		if (status == fiber.StatusOK || status == fiber.StatusNotModified) && len(c.Response().Header.Peek(fiber.HeaderCacheControl)) == 0 {
+			c.Set(fiber.HeaderCacheControl, value)
+		}
+		return nil
+	}
+}
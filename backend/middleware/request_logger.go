@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID carries the request ID in both directions
+const HeaderRequestID = "X-Request-ID"
+
+// an incoming request ID is only trusted when it can't break a log line
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID gives every request an ID, reusing the caller's X-Request-ID
+// when it looks sane, and echoes it in the response
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(HeaderRequestID)
+		if validRequestID.MatchString(id) {
+			// fasthttp reuses the header's buffer, so copy it before keeping it
+			id = utils.CopyString(id)
+		} else {
+			id = uuid.NewString()
+		}
+
+		c.Locals("request_id", id)
+		c.Set(HeaderRequestID, id)
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the ID set by RequestID
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("request_id").(string)
+	return id
+}
+
+// RequestLogger gives each request its own logger, tagged with the request
+// ID and trace ID, and logs the request once it has been handled. It must
+// run after RequestID and the tracing middleware.
+func RequestLogger(base *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		logger := base.With("request_id", GetRequestID(c))
+		if span := trace.SpanContextFromContext(c.UserContext()); span.IsValid() {
+			logger = logger.With("trace_id", span.TraceID().String())
+		}
+		c.Locals("logger", logger)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("route", c.Route().Path),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		}
+		if err != nil && fe == nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		Logger(c).LogAttrs(c.UserContext(), level, "request", attrs...)
+		return err
+	}
+}
+
+// Logger returns the request's logger, or the default logger outside of
+// RequestLogger
+func Logger(c *fiber.Ctx) *slog.Logger {
+	if logger, ok := c.Locals("logger").(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// AddLogAttrs adds attributes to every later log line of the request
+func AddLogAttrs(c *fiber.Ctx, args ...any) {
+	c.Locals("logger", Logger(c).With(args...))
+}
@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The schema as it was built by AutoMigrate before versioned migrations.
+// Up uses AutoMigrate too, so databases created the old way are adopted
+// as they are.
+
+type post0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Title     string `gorm:"not null"`
+	Content   string `gorm:"type:text"`
+	Author    string `gorm:"not null"`
+	UserID    *uint  `gorm:"index"`
+	Published bool   `gorm:"default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (post0001) TableName() string { return "posts" }
+
+type user0001 struct {
+	ID                    uint   `gorm:"primaryKey"`
+	Username              string `gorm:"uniqueIndex;not null;size:50"`
+	Email                 string `gorm:"uniqueIndex;not null;size:100"`
+	Password              string `gorm:"not null"`
+	FullName              string
+	Avatar                string
+	IsActive              bool   `gorm:"default:true"`
+	Role                  string `gorm:"not null;size:20;default:user"`
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             gorm.DeletedAt `gorm:"index"`
+	PasswordResetRequired bool           `gorm:"default:false"`
+	DeletionScheduledAt   *time.Time     `gorm:"index"`
+	DeletionPostsAction   string         `gorm:"size:20"`
+	DeletionReassignTo    *uint
+}
+
+func (user0001) TableName() string { return "users" }
+
+type session0001 struct {
+	ID             uint   `gorm:"primaryKey"`
+	UserID         uint   `gorm:"index;not null"`
+	TokenID        string `gorm:"uniqueIndex;not null;size:36"`
+	UserAgent      string `gorm:"size:255"`
+	IP             string `gorm:"size:45"`
+	CreatedAt      time.Time
+	LastSeenAt     time.Time
+	ExpiresAt      time.Time
+	RevokedAt      *time.Time `gorm:"index"`
+	ImpersonatorID *uint
+}
+
+func (session0001) TableName() string { return "sessions" }
+
+type userIdentity0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;not null"`
+	Provider  string `gorm:"uniqueIndex:idx_provider_subject;not null;size:50"`
+	Subject   string `gorm:"uniqueIndex:idx_provider_subject;not null;size:255"`
+	Email     string `gorm:"size:100"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (userIdentity0001) TableName() string { return "user_identities" }
+
+type passwordReset0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;not null"`
+	TokenHash string `gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (passwordReset0001) TableName() string { return "password_resets" }
+
+type auditLog0001 struct {
+	ID           uint      `gorm:"primaryKey"`
+	ActorID      uint      `gorm:"index;not null"`
+	Action       string    `gorm:"index;not null;size:50"`
+	TargetUserID *uint     `gorm:"index"`
+	Details      string    `gorm:"type:text"`
+	IP           string    `gorm:"size:45"`
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (auditLog0001) TableName() string { return "audit_logs" }
+
+func init() {
+	tables := []interface{}{
+		&post0001{},
+		&user0001{},
+		&session0001{},
+		&userIdentity0001{},
+		&passwordReset0001{},
+		&auditLog0001{},
+	}
+
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(tables...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(tables...)
+		},
+	})
+}
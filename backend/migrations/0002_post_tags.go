@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type tag0002 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex;not null;size:32"`
+	CreatedAt time.Time
+}
+
+func (tag0002) TableName() string { return "tags" }
+
+type postTag0002 struct {
+	PostID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey;index"`
+}
+
+func (postTag0002) TableName() string { return "post_tags" }
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "post_tags",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&tag0002{}, &postTag0002{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&postTag0002{}, &tag0002{})
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Posts count their views and comments in columns of their own, so the
+// listing can sort and page by them like by any other column.
+
+type post0003 struct {
+	ViewCount    int64 `gorm:"not null;default:0;index"`
+	CommentCount int64 `gorm:"not null;default:0;index"`
+}
+
+func (post0003) TableName() string { return "posts" }
+
+type comment0003 struct {
+	ID        uint   `gorm:"primaryKey"`
+	PostID    uint   `gorm:"index;not null"`
+	UserID    uint   `gorm:"index;not null"`
+	Author    string `gorm:"not null;size:50"`
+	Content   string `gorm:"type:text;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (comment0003) TableName() string { return "comments" }
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "comments_and_views",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"ViewCount", "CommentCount"} {
+				if !m.HasColumn(&post0003{}, field) {
+					if err := m.AddColumn(&post0003{}, field); err != nil {
+						return err
+					}
+				}
+			}
+			for _, index := range []string{"ViewCount", "CommentCount"} {
+				if !m.HasIndex(&post0003{}, index) {
+					if err := m.CreateIndex(&post0003{}, index); err != nil {
+						return err
+					}
+				}
+			}
+			return tx.AutoMigrate(&comment0003{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&comment0003{}); err != nil {
+				return err
+			}
+			for _, field := range []string{"ViewCount", "CommentCount"} {
+				if err := m.DropIndex(&post0003{}, field); err != nil {
+					return err
+				}
+			}
+			// not Migrator().DropColumn: on SQLite it copies the table and
+			// loses the other indexes of posts
+			for _, column := range []string{"view_count", "comment_count"} {
+				if err := tx.Exec("ALTER TABLE posts DROP COLUMN " + column).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+const migrationTemplate = `package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: %d,
+		Name:    %q,
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`
+
+// Create writes an empty migration numbered after the newest one into dir
+// and returns its path
+func Create(dir, name string) (string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !migrationName.MatchString(name) {
+		return "", fmt.Errorf("migration name must only contain letters, digits and underscores")
+	}
+
+	var version int64 = 1
+	if all := All(); len(all) > 0 {
+		version = all[len(all)-1].Version + 1
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.go", version, name))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, migrationTemplate, version, name); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,45 @@
+// Package migrations holds the numbered schema migrations and applies them.
+//
+// Each migration lives in its own file, NNNN_name.go, and registers itself
+// from init. Migrations describe tables with their own snapshot structs
+// rather than the models package, so later model changes can't alter what
+// an old migration does. Create new ones with `go run . migrate create <name>`.
+package migrations
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one reversible schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+var registry = map[int64]Migration{}
+
+// register adds a migration; it is called from each migration file's init
+func register(m Migration) {
+	if _, exists := registry[m.Version]; exists {
+		panic(fmt.Sprintf("migrations: version %d registered twice", m.Version))
+	}
+	if m.Up == nil || m.Down == nil {
+		panic(fmt.Sprintf("migrations: version %d needs both Up and Down", m.Version))
+	}
+	registry[m.Version] = m
+}
+
+// All returns the registered migrations ordered by version
+func All() []Migration {
+	all := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all
+}
@@ -0,0 +1,217 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOutdated means migrations are pending; run `migrate up` first
+	ErrOutdated = errors.New("database schema is outdated")
+	// ErrLocked means another instance is migrating and didn't finish in time
+	ErrLocked = errors.New("migrations are locked by another instance")
+)
+
+const (
+	// lockWait is how long to wait for another instance's migrations
+	lockWait = 2 * time.Minute
+	// staleLock is how old a lock must be before it's assumed abandoned
+	staleLock = 15 * time.Minute
+)
+
+// schemaMigration is a row of schema_migrations: one applied migration
+type schemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// migrationLock is the single row of schema_migrations_lock that exists
+// while some instance is migrating
+type migrationLock struct {
+	ID       int    `gorm:"primaryKey;autoIncrement:false"`
+	LockedBy string `gorm:"size:255"`
+	LockedAt time.Time
+}
+
+func (migrationLock) TableName() string { return "schema_migrations_lock" }
+
+// Status reports whether one migration has been applied
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies and reverts migrations against one database
+type Migrator struct {
+	db         *gorm.DB
+	log        *slog.Logger
+	migrations []Migration
+}
+
+func New(db *gorm.DB, logger *slog.Logger) *Migrator {
+	return &Migrator{db: db, log: logger, migrations: All()}
+}
+
+// Up applies every pending migration in order
+func (m *Migrator) Up() error {
+	return m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			m.log.Info("applying migration", "version", mig.Version, "name", mig.Name)
+			err := m.db.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %v", mig.Version, mig.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts the last steps applied migrations, newest first
+func (m *Migrator) Down(steps int) error {
+	return m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			m.log.Info("reverting migration", "version", mig.Version, "name", mig.Name)
+			err := m.db.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, mig.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %v", mig.Version, mig.Name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// Status lists every known migration and when it was applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &row.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Check returns ErrOutdated when migrations are pending. A database that
+// has migrations this binary doesn't know about (rolled back deploy) is
+// also refused, since the code may not match its schema.
+func (m *Migrator) Check() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	var pending int
+	known := make(map[int64]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		if _, ok := applied[mig.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d migration(s) pending, run `migrate up`", ErrOutdated, pending)
+	}
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("database has migration %04d, which this build doesn't know about", version)
+		}
+	}
+	return nil
+}
+
+// applied loads schema_migrations, creating the table on first use
+func (m *Migrator) applied() (map[int64]schemaMigration, error) {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// withLock runs fn while holding the migration lock. The lock is a row with
+// a fixed primary key, so only one instance can insert it; the others wait.
+func (m *Migrator) withLock(fn func() error) error {
+	if err := m.db.AutoMigrate(&migrationLock{}); err != nil {
+		return err
+	}
+
+	host, _ := os.Hostname()
+	lock := migrationLock{ID: 1, LockedBy: fmt.Sprintf("%s:%d", host, os.Getpid())}
+
+	deadline := time.Now().Add(lockWait)
+	for {
+		lock.LockedAt = time.Now()
+		if err := m.db.Create(&lock).Error; err == nil {
+			break
+		}
+
+		// take over a lock left behind by an instance that crashed mid-migration
+		var held migrationLock
+		if err := m.db.First(&held, 1).Error; err == nil && time.Since(held.LockedAt) > staleLock {
+			m.log.Warn("removing stale migration lock", "locked_by", held.LockedBy, "locked_at", held.LockedAt.Format(time.RFC3339))
+			m.db.Where("id = ? AND locked_at = ?", 1, held.LockedAt).Delete(&migrationLock{})
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+		time.Sleep(time.Second)
+	}
+	defer m.db.Delete(&migrationLock{}, 1)
+
+	return fn()
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AuditLog records an administrative action and who performed it
+type AuditLog struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ActorID      uint      `json:"actor_id" gorm:"index;not null"`
+	Action       string    `json:"action" gorm:"index;not null;size:50"`
+	TargetUserID *uint     `json:"target_user_id,omitempty" gorm:"index"`
+	Details      string    `json:"details" gorm:"type:text"`
+	IP           string    `json:"ip" gorm:"size:45"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
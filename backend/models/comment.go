@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is a signed-in user's reply to a post. Adding or removing one
+// also updates the post's CommentCount, in the same transaction.
+type Comment struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	PostID    uint           `json:"post_id" gorm:"index;not null"`
+	UserID    uint           `json:"user_id" gorm:"index;not null"`
+	Author    string         `json:"author" gorm:"not null;size:50"`
+	Content   string         `json:"content" gorm:"type:text;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
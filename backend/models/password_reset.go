@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PasswordReset is a single-use token for setting a new password.
+// Only the SHA-256 hash of the token is stored.
+type PasswordReset struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -7,12 +7,25 @@ import (
 )
 
 type Post struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"not null"`
-	Content   string         `json:"content" gorm:"type:text"`
-	Author    string         `json:"author" gorm:"not null"`
-	Published bool           `json:"published" gorm:"default:false"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-}
\ No newline at end of file
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Title        string         `json:"title" gorm:"not null"`
+	Content      string         `json:"content" gorm:"type:text"`
+	Author       string         `json:"author" gorm:"not null"`
+	UserID       *uint          `json:"user_id,omitempty" gorm:"index"`
+	Published    bool           `json:"published" gorm:"default:false"`
+	ViewCount    int64          `json:"view_count" gorm:"not null;default:0;index"`
+	CommentCount int64          `json:"comment_count" gorm:"not null;default:0;index"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	Tags         []Tag          `json:"tags" gorm:"many2many:post_tags"`
+}
+
+// PostsOf selects the posts linked to the user's account. Older posts
+// without a user_id are nobody's: their author is free text that anyone
+// could have set, so they are never matched by username.
+func PostsOf(user User) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", user.ID)
+	}
+}
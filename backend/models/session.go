@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Session is one login of a user on one device. The JWT issued at login
+// carries TokenID as its "jti" claim, so revoking the row revokes the token.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"index;not null"`
+	TokenID    string     `json:"-" gorm:"uniqueIndex;not null;size:36"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IP         string     `json:"ip" gorm:"size:45"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+
+	// ImpersonatorID is the admin who opened this session on the user's behalf
+	ImpersonatorID *uint `json:"impersonator_id,omitempty"`
+
+	Current bool `json:"current" gorm:"-"`
+}
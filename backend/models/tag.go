@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Tag labels posts; names are stored normalized (see NormalizeTag)
+type Tag struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null;size:32"`
+	CreatedAt time.Time `json:"-"`
+}
@@ -7,6 +7,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Username  string         `json:"username" gorm:"uniqueIndex;not null;size:50"`
@@ -15,7 +21,17 @@ type User struct {
 	FullName  string         `json:"full_name"`
 	Avatar    string         `json:"avatar"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	Role      string         `json:"role" gorm:"not null;size:20;default:user"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Set by an admin; the user can't log in until the password is reset
+	PasswordResetRequired bool `json:"password_reset_required" gorm:"default:false"`
+
+	// Account deletion requested by the user, carried out by the purger
+	// once DeletionScheduledAt has passed
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" gorm:"index"`
+	DeletionPostsAction string     `json:"-" gorm:"size:20"`
+	DeletionReassignTo  *uint      `json:"-"`
 }
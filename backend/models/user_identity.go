@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UserIdentity links a User to an account at an external OpenID Connect
+// provider. Subject is the provider's stable "sub" claim for that account.
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Provider  string    `json:"provider" gorm:"uniqueIndex:idx_provider_subject;not null;size:50"`
+	Subject   string    `json:"-" gorm:"uniqueIndex:idx_provider_subject;not null;size:255"`
+	Email     string    `json:"email" gorm:"size:100"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
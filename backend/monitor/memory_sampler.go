@@ -0,0 +1,220 @@
+// Package monitor watches the process's own resource usage.
+package monitor
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+)
+
+// Kinds of memory alert
+const (
+	AlertHeapGrowth      = "heap_growth"
+	AlertGoroutineGrowth = "goroutine_growth"
+)
+
+// MemoryAlert is raised when a resource keeps growing faster than allowed
+type MemoryAlert struct {
+	Kind      string    `json:"kind"`
+	Rate      float64   `json:"rate_per_hour"` // MB/h for the heap, goroutines/h
+	Threshold float64   `json:"threshold_per_hour"`
+	Since     time.Time `json:"since"`
+}
+
+// MemorySampler records memory statistics in a ring buffer at a fixed
+// interval and watches the recent samples for steady growth
+type MemorySampler struct {
+	cfg config.MemoryConfig
+	log *slog.Logger
+
+	mu      sync.RWMutex
+	samples []middleware.DetailedMemoryStats
+	next    int  // where the next sample goes
+	full    bool // samples has wrapped around
+	alerts  map[string]MemoryAlert
+}
+
+func NewMemorySampler(cfg config.MemoryConfig, logger *slog.Logger) *MemorySampler {
+	return &MemorySampler{
+		cfg:     cfg,
+		log:     logger,
+		samples: make([]middleware.DetailedMemoryStats, cfg.HistorySize),
+		alerts:  map[string]MemoryAlert{},
+	}
+}
+
+// Start samples now and then every configured interval, until the returned
+// function is called
+func (s *MemorySampler) Start() (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	ticker := time.NewTicker(s.cfg.SampleInterval)
+
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for {
+			s.Sample()
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// Sample records the current statistics and re-evaluates the trends
+func (s *MemorySampler) Sample() {
+	sample := middleware.GetDetailedMemoryStats()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+
+	s.detectTrends(sample.Timestamp)
+}
+
+// History returns the samples taken since the given time, oldest first.
+// With maxPoints > 0 the result is downsampled to at most that many points.
+func (s *MemorySampler) History(since time.Time, maxPoints int) []middleware.DetailedMemoryStats {
+	s.mu.RLock()
+	history := s.ordered(since)
+	s.mu.RUnlock()
+
+	if maxPoints > 0 && len(history) > maxPoints {
+		history = downsample(history, maxPoints)
+	}
+	return history
+}
+
+// Alerts returns the alerts currently raised
+func (s *MemorySampler) Alerts() []MemoryAlert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := make([]MemoryAlert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// Alerting reports whether an alert of the given kind is raised
+func (s *MemorySampler) Alerting(kind string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.alerts[kind]
+	return ok
+}
+
+// ordered returns the buffered samples taken since the given time, oldest
+// first. The caller must hold the lock.
+func (s *MemorySampler) ordered(since time.Time) []middleware.DetailedMemoryStats {
+	var all []middleware.DetailedMemoryStats
+	if s.full {
+		all = append(all, s.samples[s.next:]...)
+	}
+	all = append(all, s.samples[:s.next]...)
+
+	start := 0
+	for start < len(all) && all[start].Timestamp.Before(since) {
+		start++
+	}
+	return all[start:]
+}
+
+// detectTrends fits a line through the samples in the trend window and
+// raises or clears alerts. The caller must hold the lock.
+func (s *MemorySampler) detectTrends(now time.Time) {
+	window := s.ordered(now.Add(-s.cfg.TrendWindow))
+	// wait until the window is mostly covered, or a startup ramp looks like a leak
+	if len(window) < 2 || now.Sub(window[0].Timestamp) < s.cfg.TrendWindow*3/4 {
+		return
+	}
+
+	heapRate := growthPerHour(window, func(m middleware.DetailedMemoryStats) float64 { return m.HeapAllocMB })
+	goroutineRate := growthPerHour(window, func(m middleware.DetailedMemoryStats) float64 { return float64(m.Goroutines) })
+
+	s.updateAlert(AlertHeapGrowth, heapRate, float64(s.cfg.HeapGrowthAlertMB), now)
+	s.updateAlert(AlertGoroutineGrowth, goroutineRate, float64(s.cfg.GoroutineGrowthAlert), now)
+}
+
+// updateAlert raises the alert when rate is over the threshold and clears it
+// once it drops below; both transitions are logged. A zero threshold disables it.
+func (s *MemorySampler) updateAlert(kind string, rate, threshold float64, now time.Time) {
+	_, raised := s.alerts[kind]
+	switch {
+	case threshold > 0 && rate > threshold:
+		if !raised {
+			s.log.Warn("memory alert raised", "kind", kind, "rate_per_hour", rate, "window", s.cfg.TrendWindow.String(), "threshold_per_hour", threshold)
+			s.alerts[kind] = MemoryAlert{Kind: kind, Threshold: threshold, Since: now}
+		}
+		alert := s.alerts[kind]
+		alert.Rate = rate
+		s.alerts[kind] = alert
+	case raised:
+		s.log.Info("memory alert resolved", "kind", kind, "rate_per_hour", rate)
+		delete(s.alerts, kind)
+	}
+}
+
+// growthPerHour is the least-squares slope of value over time, per hour
+func growthPerHour(samples []middleware.DetailedMemoryStats, value func(middleware.DetailedMemoryStats) float64) float64 {
+	t0 := samples[0].Timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, m := range samples {
+		x := m.Timestamp.Sub(t0).Hours()
+		y := value(m)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// downsample splits the samples into maxPoints buckets and keeps the last
+// sample of each, with the bucket's peak heap and goroutine count so that
+// spikes survive
+func downsample(samples []middleware.DetailedMemoryStats, maxPoints int) []middleware.DetailedMemoryStats {
+	result := make([]middleware.DetailedMemoryStats, 0, maxPoints)
+	for i := 0; i < maxPoints; i++ {
+		bucket := samples[i*len(samples)/maxPoints : (i+1)*len(samples)/maxPoints]
+		if len(bucket) == 0 {
+			continue
+		}
+
+		point := bucket[len(bucket)-1]
+		for _, m := range bucket {
+			if m.HeapAlloc > point.HeapAlloc {
+				point.HeapAlloc = m.HeapAlloc
+				point.HeapAllocMB = m.HeapAllocMB
+			}
+			if m.Goroutines > point.Goroutines {
+				point.Goroutines = m.Goroutines
+			}
+		}
+		result = append(result, point)
+	}
+	return result
+}
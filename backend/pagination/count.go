@@ -0,0 +1,69 @@
+package pagination
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCachedCounts bounds the cache when many different filters are listed
+const maxCachedCounts = 1024
+
+// CountCache remembers row counts per listing for a while. A cached count
+// serves as the estimated total of cursor pages, which then cost no
+// COUNT(*) at all for most requests.
+type CountCache struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	counts map[string]cachedCount
+}
+
+type cachedCount struct {
+	n       int64
+	expires time.Time
+}
+
+func NewCountCache(ttl time.Duration) *CountCache {
+	return &CountCache{ttl: ttl, counts: make(map[string]cachedCount)}
+}
+
+// Get returns the cached count for key, calling count when there is none
+// or it has expired
+func (c *CountCache) Get(key string, count func() (int64, error)) (int64, error) {
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.counts[key]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.n, nil
+	}
+
+	n, err := count()
+	if err != nil {
+		return 0, err
+	}
+	c.Set(key, n)
+	return n, nil
+}
+
+// Set stores an exact count, e.g. one a client asked for
+func (c *CountCache) Set(key string, n int64) {
+	if c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.counts) >= maxCachedCounts {
+		for k, v := range c.counts {
+			if now.After(v.expires) {
+				delete(c.counts, k)
+			}
+		}
+		// still full of live entries: start over rather than track usage
+		if len(c.counts) >= maxCachedCounts {
+			clear(c.counts)
+		}
+	}
+	c.counts[key] = cachedCount{n: n, expires: now.Add(c.ttl)}
+}
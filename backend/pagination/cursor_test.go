@@ -0,0 +1,151 @@
+package pagination
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := NewSigner("0123456789abcdef0123456789abcdef")
+	at := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.FixedZone("ICT", 7*3600))
+	for _, cur := range []Cursor{
+		{Value: at, ID: 7},
+		{Value: at.UTC(), ID: 7, Before: true},
+		{Value: "Go & friends", ID: 3},
+		{Value: int64(0), ID: 4},
+		{Value: int64(1) << 40, ID: 5, Before: true},
+	} {
+		got, err := s.Decode(s.Encode(cur, "scope"), "scope")
+		if err != nil {
+			t.Fatalf("Decode(Encode(%+v)): %v", cur, err)
+		}
+		if want, ok := cur.Value.(time.Time); ok {
+			// same instant, and same offset: SQLite compares the text
+			if !got.Value.(time.Time).Equal(want) || got.Value.(time.Time).Format(time.RFC3339Nano) != want.Format(time.RFC3339Nano) {
+				t.Errorf("time came back as %v, want %v", got.Value, want)
+			}
+			got.Value, cur.Value = nil, nil
+		}
+		if !reflect.DeepEqual(got, cur) {
+			t.Errorf("Decode = %+v, want %+v", got, cur)
+		}
+	}
+}
+
+func TestSignerRejects(t *testing.T) {
+	s := NewSigner("0123456789abcdef0123456789abcdef")
+	token := s.Encode(Cursor{Value: "b", ID: 2}, "author=ann")
+	payload, mac, _ := strings.Cut(token, ".")
+	forged := s.Encode(Cursor{Value: "z", ID: 9}, "author=ann")
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	for name, tc := range map[string]struct {
+		signer       *Signer
+		token, scope string
+	}{
+		"other scope":      {s, token, "author=bob"},
+		"other secret":     {NewSigner("another secret, also 32 bytes ok"), token, "author=ann"},
+		"swapped payload":  {s, forgedPayload + "." + mac, "author=ann"},
+		"flipped mac":      {s, payload + "." + strings.ToUpper(mac[:1]) + strings.ToLower(mac[1:]), "author=ann"},
+		"no mac":           {s, payload, "author=ann"},
+		"garbage":          {s, "not a cursor", "author=ann"},
+		"empty":            {s, "", "author=ann"},
+		"truncated":        {s, token[:len(token)-2], "author=ann"},
+		"random signer":    {NewSigner(""), token, "author=ann"},
+		"payload appended": {s, payload + "x." + mac, "author=ann"},
+	} {
+		if _, err := tc.signer.Decode(tc.token, tc.scope); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: Decode = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+type row struct {
+	ID        uint
+	CreatedAt time.Time
+}
+
+// Rows written in UTC and read by a process in another time zone, as when
+// a server's TZ changes: the cursors still page through every row once.
+func TestKeysetPaging(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("ICT", 7*3600)
+	t.Cleanup(func() { time.Local = local })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rows.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&row{}); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []row{
+		{ID: 1, CreatedAt: base},
+		{ID: 2, CreatedAt: base.Add(time.Hour)},
+		{ID: 3, CreatedAt: base.Add(time.Hour)}, // a tie, broken by id
+		{ID: 4, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 5, CreatedAt: base.Add(3 * time.Hour)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSigner("0123456789abcdef0123456789abcdef")
+	order := Order{Column: "created_at", Desc: true}
+	page := func(token string) []row {
+		t.Helper()
+		var cur *Cursor
+		if token != "" {
+			c, err := s.Decode(token, "all")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cur = &c
+		}
+		var got []row
+		if err := db.Scopes(Keyset(order, cur)).Limit(2).Find(&got).Error; err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	ids := func(rows []row) []uint {
+		out := make([]uint, len(rows))
+		for i, r := range rows {
+			out[i] = r.ID
+		}
+		return out
+	}
+
+	// forwards, newest first
+	var seen []uint
+	var pages [][]row
+	token := ""
+	for i := 0; i < 5; i++ {
+		rows := page(token)
+		if len(rows) == 0 {
+			break
+		}
+		pages = append(pages, rows)
+		seen = append(seen, ids(rows)...)
+		last := rows[len(rows)-1]
+		token = s.Encode(Cursor{Value: last.CreatedAt, ID: last.ID}, "all")
+	}
+	if want := []uint{5, 4, 3, 2, 1}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("paging forwards: %v, want %v", seen, want)
+	}
+
+	// backwards from the last page: rows come out in reverse
+	first := pages[len(pages)-1][0]
+	back := page(s.Encode(Cursor{Value: first.CreatedAt, ID: first.ID, Before: true}, "all"))
+	if got := ids(back); !reflect.DeepEqual(got, []uint{2, 3}) {
+		t.Errorf("paging backwards from id %d: %v, want [2 3]", first.ID, got)
+	}
+}
@@ -1,31 +1,262 @@
 package routes
 
 import (
+	"blog-app-backend/app"
+	"blog-app-backend/config"
 	"blog-app-backend/handlers"
 	"blog-app-backend/middleware"
+	"blog-app-backend/models"
+	"blog-app-backend/tracing"
+
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 )
 
-func Register(app *fiber.App) {
-	api := app.Group("/api")
+// New builds a Fiber app with every route wired to the given container
+func New(a *app.App) *fiber.App {
+	server := fiber.New(withProxies(fiber.Config{}, a.Config.Server))
+
+	// Tag every request with an ID, trace it and log it with its own logger
+	server.Use(middleware.RequestID())
+	server.Use(tracing.Middleware())
+	server.Use(middleware.RequestLogger(a.Logger))
+
+	// Count and time every request for /metrics
+	server.Use(a.Metrics.Middleware())
+
+	// Enable CORS for frontend communication
+	server.Use(cors.New(cors.Config{
+		AllowOrigins:     strings.Join(a.Config.Server.CORSOrigins, ", "),
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID, traceparent, tracestate",
+		ExposeHeaders:    "X-Request-ID",
+		AllowMethods:     "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+		AllowCredentials: true,
+	}))
+
+	// API routes; / is the home page when the backend serves pages
+	if !a.Config.Pages.Enabled {
+		server.Get("/", func(c *fiber.Ctx) error {
+			return c.JSON(fiber.Map{
+				"message": "Blog App API is running!",
+				"status":  "success",
+			})
+		})
+	}
+
+	// Prometheus metrics, for the diagnostics' allowed IPs only; on the
+	// diagnostics listener instead when there is one
+	if a.Config.Diagnostics.Addr == "" {
+		server.Get("/metrics", middleware.IPAllowList(a.Config.Diagnostics.AllowedIPs), a.Metrics.Handler())
+	}
+
+	// Health checks and Kubernetes-style probes
+	health := handlers.NewHealthHandler(a)
+	server.Get("/health", health.Health)
+	server.Get("/livez", health.Livez)
+	server.Get("/readyz", health.Readyz)
+
+	// Register API routes
+	Register(server, a)
+
+	return server
+}
+
+// withProxies makes c.IP() the client's address as reported by a trusted
+// reverse proxy; requests from anywhere else keep their own address
+func withProxies(fc fiber.Config, cfg config.ServerConfig) fiber.Config {
+	if cfg.ProxyHeader == "" {
+		return fc
+	}
+	fc.ProxyHeader = cfg.ProxyHeader
+	fc.EnableTrustedProxyCheck = true
+	fc.TrustedProxies = cfg.TrustedProxies
+	fc.EnableIPValidation = true
+	return fc
+}
+
+// NewStatic builds a Fiber app with only the public pages, feeds and
+// sitemaps, without rate limits or caching, for rendering them to files
+func NewStatic(a *app.App) *fiber.App {
+	server := fiber.New()
+
+	feeds := handlers.NewFeedHandler(a)
+	server.Get("/feed.:format", feeds.Feed)
+	server.Get("/authors/:author/feed.:format", feeds.Feed)
+	server.Get("/tags/:tag/feed.:format", feeds.Feed)
+
+	sitemaps := handlers.NewSitemapHandler(a)
+	server.Get("/robots.txt", sitemaps.Robots)
+	server.Get("/sitemap.xml", sitemaps.Index)
+	server.Get("/sitemaps/:name", sitemaps.Sitemap)
+
+	if a.Theme != nil {
+		pages := handlers.NewPagesHandler(a)
+		server.Get("/", pages.Home)
+		server.Get("/posts/:id", pages.Post)
+		server.Get("/tags/:tag", pages.Tag)
+		server.Get("/authors/:author", pages.Author)
+		server.Get("/archive", pages.Archive)
+	}
+	return server
+}
+
+func Register(router *fiber.App, a *app.App) {
+	auth := handlers.NewAuthHandler(a)
+	posts := handlers.NewPostsHandler(a)
+	sessions := handlers.NewSessionsHandler(a)
+	account := handlers.NewAccountHandler(a)
+	admin := handlers.NewAdminHandler(a)
+	search := handlers.NewSearchHandler(a)
+	feeds := handlers.NewFeedHandler(a)
+	public := handlers.NewPublicHandler(a)
+	comments := handlers.NewCommentsHandler(a)
+	sitemaps := handlers.NewSitemapHandler(a)
+
+	jwtProtected := middleware.JWTProtected(a.Sessions, a.Audit)
+
+	// Public keys for verifying our JWTs
+	router.Get("/.well-known/jwks.json", auth.GetJWKS)
+
+	// Anyone may read published posts, within a rate limit per IP
+	rateLimit := middleware.RateLimitByIP(a.Config.Public.RateLimit, a.Config.Public.RateWindow)
+	cache := middleware.PublicCache(a.Config.Public.CacheMaxAge)
+	bodyETag := etag.New(etag.Config{Weak: true})
+
+	// Crawler instructions
+	router.Get("/robots.txt", rateLimit, cache, sitemaps.Robots)
+
+	// Server-rendered pages for crawlers and readers without JavaScript,
+	// and the feeds and sitemaps of the published posts, which link to
+	// those pages: the frontend has no page per post to link to instead
+	if a.Theme != nil {
+		router.Get("/feed.:format", rateLimit, feeds.Feed)
+		router.Get("/authors/:author/feed.:format", rateLimit, feeds.Feed)
+		router.Get("/tags/:tag/feed.:format", rateLimit, feeds.Feed)
+		router.Get("/sitemap.xml", rateLimit, cache, bodyETag, sitemaps.Index)
+		router.Get("/sitemaps/:name", rateLimit, cache, bodyETag, sitemaps.Sitemap)
+
+		pages := handlers.NewPagesHandler(a)
+		router.Get("/", rateLimit, cache, bodyETag, pages.Home)
+		router.Get("/posts/:id", rateLimit, cache, bodyETag, pages.Post)
+		router.Get("/tags/:tag", rateLimit, cache, bodyETag, pages.Tag)
+		router.Get("/authors/:author", rateLimit, cache, bodyETag, pages.Author)
+		router.Get("/archive", rateLimit, cache, bodyETag, pages.Archive)
+	}
+
+	api := router.Group("/api")
 
 	// Public routes (no authentication required)
-	auth := api.Group("/auth")
-	auth.Post("/register", handlers.Register)
-	auth.Post("/login", handlers.Login)
-	auth.Post("/logout", handlers.Logout)
+	authRoutes := api.Group("/auth")
+	authRoutes.Post("/register", auth.Register)
+	authRoutes.Post("/login", auth.Login)
+	authRoutes.Post("/logout", auth.Logout)
+	authRoutes.Post("/password-reset", auth.ResetPassword)
+
+	// Single sign-on with configured OpenID Connect providers
+	authRoutes.Get("/oidc/:provider/login", auth.OIDCLogin)
+	authRoutes.Get("/oidc/:provider/callback", auth.OIDCCallback)
 
 	// Public health check
 	api.Get("/health", func(c *fiber.Ctx) error { return c.SendString("ok") })
 
-	// Memory monitoring endpoints (public for testing)
-	api.Get("/memory", handlers.GetMemoryStats)
-	api.Post("/memory/gc", handlers.ForceGC)
+	// Public read-only API; writing goes through the protected routes
+	publicRoutes := api.Group("/public", rateLimit, cache, bodyETag)
+	publicRoutes.Get("/posts", public.ListPosts)
+	publicRoutes.Get("/posts/:id", public.GetPost)
+	publicRoutes.Get("/posts/:id/comments", comments.ListComments)
+	publicRoutes.Get("/authors", public.ListAuthors)
+	publicRoutes.Get("/authors/:author", public.GetAuthor)
+	publicRoutes.Get("/tags", public.ListTags)
+	if a.Theme != nil {
+		publicRoutes.Get("/feed.:format", feeds.Feed)
+		publicRoutes.Get("/authors/:author/feed.:format", feeds.Feed)
+		publicRoutes.Get("/tags/:tag/feed.:format", feeds.Feed)
+	}
 
 	// Protected routes (authentication required)
-	protected := api.Group("/", middleware.JWTProtected())
+	protected := api.Group("/", jwtProtected)
 	// Posts routes (authenticated users only)
-	protected.Get("/posts", handlers.ListPublicPosts)
-	protected.Post("/posts/create", handlers.CreatePost)
+	protected.Get("/posts", posts.ListPublicPosts)
+	protected.Post("/posts/create", posts.CreatePost)
+	protected.Put("/posts/:id", posts.UpdatePost)
+	protected.Delete("/posts/:id", posts.DeletePost)
+	protected.Post("/posts/:id/comments", comments.CreateComment)
+	protected.Delete("/posts/:id/comments/:comment_id", comments.DeleteComment)
+
+	// Full-text search over published posts
+	protected.Get("/search", search.Search)
+	protected.Get("/search/suggest", search.Suggest)
+
+	// Session management (devices the user is logged in on)
+	protected.Get("/sessions", sessions.ListSessions)
+	protected.Delete("/sessions/:id", sessions.RevokeSession)
+
+	// Personal data export and account deletion
+	protected.Get("/users/me/export", account.ExportMyData)
+	protected.Delete("/users/me", account.DeleteMyAccount)
+	protected.Post("/users/me/deletion/cancel", account.CancelAccountDeletion)
+
+	// Admin user management (admins only); the "/" group above has already
+	// run jwtProtected for everything under /api registered after it
+	adminRoutes := api.Group("/admin", middleware.RequireRole(models.RoleAdmin))
+	adminRoutes.Get("/users", admin.ListUsers)
+	adminRoutes.Patch("/users/:id/active", admin.SetUserActive)
+	adminRoutes.Patch("/users/:id/role", admin.SetUserRole)
+	adminRoutes.Post("/users/:id/password-reset", admin.ForcePasswordReset)
+	adminRoutes.Post("/users/:id/impersonate", admin.ImpersonateUser)
+	adminRoutes.Get("/users/:id/stats", admin.GetUserStats)
+	adminRoutes.Get("/audit", admin.ListAuditLogs)
+
+	// Diagnostics, unless they have a listener of their own
+	if a.Config.Diagnostics.Addr == "" {
+		registerDiagnostics(adminRoutes.Group("/debug"), a)
+	}
+}
+
+// NewDiagnostics builds the separate diagnostics server, or returns nil
+// when the diagnostics are served under /api/admin/debug
+func NewDiagnostics(a *app.App) *fiber.App {
+	if a.Config.Diagnostics.Addr == "" {
+		return nil
+	}
+
+	server := fiber.New(withProxies(fiber.Config{DisableStartupMessage: true}, a.Config.Server))
+	server.Use(middleware.RequestID())
+	server.Use(tracing.Middleware())
+	server.Use(middleware.RequestLogger(a.Logger))
+	// scrapers have no login: the allowed IPs are enough for metrics
+	server.Get("/metrics", middleware.IPAllowList(a.Config.Diagnostics.AllowedIPs), a.Metrics.Handler())
+	debug := server.Group("/debug",
+		middleware.JWTProtected(a.Sessions, a.Audit),
+		middleware.RequireRole(models.RoleAdmin),
+	)
+	registerDiagnostics(debug, a)
+	return server
+}
+
+// registerDiagnostics adds the profiling, memory and database pool endpoints
+// to a group that already requires an admin. They also require an allowed IP.
+func registerDiagnostics(router fiber.Router, a *app.App) {
+	diagnostics := handlers.NewDiagnosticsHandler(a)
+	memory := handlers.NewMemoryHandler(a)
+
+	router.Use(middleware.IPAllowList(a.Config.Diagnostics.AllowedIPs))
+
+	// net/http/pprof profiles
+	router.Get("/pprof/", diagnostics.Index)
+	router.Get("/pprof/profile", diagnostics.CPUProfile)
+	router.Get("/pprof/trace", diagnostics.Trace)
+	router.Get("/pprof/:name", diagnostics.Profile)
+
+	// Memory monitoring
+	router.Get("/memory", handlers.GetMemoryStats)
+	router.Post("/memory/gc", handlers.ForceGC)
+	router.Get("/memory/history", memory.GetMemoryHistory)
+	router.Get("/memory/heap-profile", memory.CaptureHeapProfile)
+
+	// Database connection pool statistics
+	router.Get("/db/stats", handlers.NewDBStatsHandler(a).GetDBStats)
 }
@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"blog-app-backend/app"
+	"blog-app-backend/config"
+	"blog-app-backend/middleware"
+	"blog-app-backend/migrations"
+)
+
+// newTestApp builds an app on a SQLite database of its own, with a fresh
+// signing key; configure adjusts the defaults before it is built
+func newTestApp(t *testing.T, configure func(*config.Config)) *app.App {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.Path = filepath.Join(t.TempDir(), "blog.db")
+	cfg.JWT.KeysDir = t.TempDir()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(cfg.JWT.KeysDir, "test.pem"), block, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+
+	a, err := app.New(&cfg, app.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+// Requests made with fiber's Test come from 0.0.0.0
+
+func TestAllowListBehindTrustedProxy(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		server  config.ServerConfig
+		realIP  string
+		allowed bool
+	}{
+		{"no proxy, header ignored", config.ServerConfig{}, "127.0.0.1", false},
+		{"trusted proxy, allowed client", config.ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"0.0.0.0"}}, "127.0.0.1", true},
+		{"trusted proxy, other client", config.ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"0.0.0.0"}}, "203.0.113.7", false},
+		{"untrusted proxy", config.ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"10.0.0.0/8"}}, "127.0.0.1", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := fiber.New(withProxies(fiber.Config{}, tc.server))
+			server.Get("/", middleware.IPAllowList([]string{"127.0.0.1"}), func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Real-IP", tc.realIP)
+			resp, err := server.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed := resp.StatusCode == http.StatusOK; allowed != tc.allowed {
+				t.Errorf("allowed = %v, want %v (status %d)", allowed, tc.allowed, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRateLimitPerClientBehindTrustedProxy(t *testing.T) {
+	server := fiber.New(withProxies(fiber.Config{}, config.ServerConfig{
+		ProxyHeader:    "X-Real-IP",
+		TrustedProxies: []string{"0.0.0.0"},
+	}))
+	server.Get("/", middleware.RateLimitByIP(2, time.Minute), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	get := func(client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Real-IP", client)
+		resp, err := server.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	for i := 0; i < 2; i++ {
+		if status := get("198.51.100.1"); status != http.StatusOK {
+			t.Fatalf("request %d of the first client: status %d", i+1, status)
+		}
+	}
+	if status := get("198.51.100.1"); status != http.StatusTooManyRequests {
+		t.Errorf("third request of the first client: status %d, want 429", status)
+	}
+	if status := get("198.51.100.2"); status != http.StatusOK {
+		t.Errorf("another client behind the same proxy: status %d, want its own limit", status)
+	}
+}
+
+func TestMetricsNeedAnAllowedIP(t *testing.T) {
+	get := func(server *fiber.App) int {
+		resp, err := server.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	a := newTestApp(t, nil)
+	if status := get(New(a)); status != http.StatusForbidden {
+		t.Errorf("from a client outside diagnostics.allowed_ips: status %d, want 403", status)
+	}
+
+	a = newTestApp(t, func(cfg *config.Config) { cfg.Diagnostics.AllowedIPs = []string{"0.0.0.0"} })
+	if status := get(New(a)); status != http.StatusOK {
+		t.Errorf("from an allowed client: status %d, want 200", status)
+	}
+
+	a = newTestApp(t, func(cfg *config.Config) {
+		cfg.Diagnostics.Addr = "127.0.0.1:6060"
+		cfg.Diagnostics.AllowedIPs = []string{"0.0.0.0"}
+	})
+	if status := get(New(a)); status == http.StatusOK {
+		t.Error("metrics are on the main listener as well as the diagnostics one")
+	}
+	if status := get(NewDiagnostics(a)); status != http.StatusOK {
+		t.Errorf("on the diagnostics listener: status %d, want 200", status)
+	}
+}
+
+func TestFeedsAndSitemapsNeedPages(t *testing.T) {
+	get := func(server *fiber.App, path string) int {
+		resp, err := server.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	paths := []string{"/feed.rss", "/feed.atom", "/api/public/feed.json", "/sitemap.xml", "/sitemaps/posts-1.xml"}
+
+	// without pages, the posts have no page for items and URLs to link to;
+	// unknown paths under /api meet the JWT check first
+	a := newTestApp(t, nil)
+	if err := migrations.New(a.DB, a.Logger).Up(); err != nil {
+		t.Fatal(err)
+	}
+	server := New(a)
+	for _, path := range paths {
+		if status := get(server, path); status == http.StatusOK {
+			t.Errorf("%s is served without pages", path)
+		}
+	}
+
+	a = newTestApp(t, func(cfg *config.Config) {
+		cfg.Pages.Enabled = true
+		cfg.Pages.BaseURL = "https://blog.example.com"
+	})
+	if err := migrations.New(a.DB, a.Logger).Up(); err != nil {
+		t.Fatal(err)
+	}
+	server = New(a)
+	for _, path := range paths {
+		if status := get(server, path); status != http.StatusOK {
+			t.Errorf("%s with pages: status %d, want 200", path, status)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package search is an in-memory full-text index of the published posts,
+// ranked with BM25.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is one term of a text and where it came from
+type Token struct {
+	Term string
+	// Pos counts tokens from the start of the text; phrases match on it
+	Pos int
+	// Start and End are byte offsets of the token in the text
+	Start, End int
+}
+
+// Analyze splits text into index terms.
+//
+// Latin-script and other space-separated words are lower-cased, and plain
+// ASCII words are stemmed. Thai is written without spaces between words,
+// so a run of Thai is split into overlapping pairs of characters instead:
+// "สวัสดี" becomes "สวั", "วัส", "สดี". A Thai word in a query then matches
+// wherever its pairs appear next to each other, without a dictionary.
+func Analyze(text string) []Token {
+	var tokens []Token
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isThai(r):
+			end := i
+			for end < len(text) {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if !isThai(r) {
+					break
+				}
+				end += size
+			}
+			tokens = appendThai(tokens, text, i, end)
+			i = end
+		case isWordRune(r):
+			end := i
+			for end < len(text) {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if !isWordRune(r) || isThai(r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, Token{Term: normalize(text[i:end]), Pos: len(tokens), Start: i, End: end})
+			i = end
+		default:
+			i += size
+		}
+	}
+	return tokens
+}
+
+// Terms returns just the terms of Analyze
+func Terms(text string) []string {
+	tokens := Analyze(text)
+	terms := make([]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = t.Term
+	}
+	return terms
+}
+
+func isThai(r rune) bool {
+	return unicode.Is(unicode.Thai, r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// normalize lower-cases a word and stems it if it is plain English
+func normalize(word string) string {
+	word = strings.ToLower(word)
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+	return Stem(word)
+}
+
+// appendThai adds the character pairs of text[start:end]. A character is a
+// base letter with its vowel and tone marks, so pairs never split a mark
+// from its letter.
+func appendThai(tokens []Token, text string, start, end int) []Token {
+	// byte offsets where each character starts, plus end
+	var bounds []int
+	for i := start; i < end; {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if len(bounds) == 0 || !unicode.Is(unicode.Mn, r) {
+			bounds = append(bounds, i)
+		}
+		i += size
+	}
+	bounds = append(bounds, end)
+
+	if len(bounds) == 2 {
+		return append(tokens, Token{Term: text[start:end], Pos: len(tokens), Start: start, End: end})
+	}
+	for k := 0; k+2 < len(bounds); k++ {
+		s, e := bounds[k], bounds[k+2]
+		tokens = append(tokens, Token{Term: text[s:e], Pos: len(tokens), Start: s, End: e})
+	}
+	return tokens
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Connected CONNECTING connection", []string{"connect", "connect", "connect"}},
+		{"e-mail", []string{"e", "mail"}},
+		// non-ASCII words are lower-cased, not stemmed
+		{"Naïve CAFÉS", []string{"naïve", "cafés"}},
+		// Thai runs become overlapping character pairs; a vowel or tone
+		// mark stays with its letter
+		{"สวัสดี", []string{"สวั", "วัส", "สดี"}},
+		{"ไทย", []string{"ไท", "ทย"}},
+		{"ก", []string{"ก"}},
+		{"ภาษาไทยGo", []string{"ภา", "าษ", "ษา", "าไ", "ไท", "ทย", "go"}},
+		{"Go 1.22 ใน ไทย", []string{"go", "1", "22", "ใน", "ไท", "ทย"}},
+		{"  ... !!", []string{}},
+	} {
+		if got := Terms(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Terms(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestAnalyzeOffsets(t *testing.T) {
+	text := "Go สวัสดี"
+	tokens := Analyze(text)
+	if len(tokens) != 4 {
+		t.Fatalf("%d tokens, want 4: %+v", len(tokens), tokens)
+	}
+	for i, tok := range tokens {
+		if tok.Pos != i {
+			t.Errorf("token %d has position %d", i, tok.Pos)
+		}
+		// Thai terms are the text itself; the English one is lower-cased
+		if i > 0 && text[tok.Start:tok.End] != tok.Term {
+			t.Errorf("token %d covers %q, its term is %q", i, text[tok.Start:tok.End], tok.Term)
+		}
+	}
+	if text[tokens[0].Start:tokens[0].End] != "Go" {
+		t.Errorf("first token covers %q", text[tokens[0].Start:tokens[0].End])
+	}
+}
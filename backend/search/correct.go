@@ -0,0 +1,105 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// minCorrectLength leaves short words alone: too many words are one edit
+// away from them
+const minCorrectLength = 4
+
+// latinWord finds the words of a query that can be spell-checked
+var latinWord = regexp.MustCompile(`[A-Za-z]+`)
+
+// vocabulary counts the lower-cased English words of the indexed posts, in
+// how many posts each appears. Corrections are picked from it.
+type vocabulary map[string]int
+
+func (v vocabulary) add(words []string) {
+	for _, w := range words {
+		v[w]++
+	}
+}
+
+func (v vocabulary) remove(words []string) {
+	for _, w := range words {
+		if v[w]--; v[w] <= 0 {
+			delete(v, w)
+		}
+	}
+}
+
+// wordsOf returns the distinct lower-cased English words of texts
+func wordsOf(texts ...string) []string {
+	seen := map[string]bool{}
+	var words []string
+	for _, text := range texts {
+		for _, w := range latinWord.FindAllString(text, -1) {
+			w = strings.ToLower(w)
+			if len(w) >= minCorrectLength && !seen[w] {
+				seen[w] = true
+				words = append(words, w)
+			}
+		}
+	}
+	return words
+}
+
+// correct returns the closest known word to an unknown one: within one edit
+// for words up to 5 letters and two edits for longer ones, preferring fewer
+// edits and then more common words. ok is false without a candidate.
+func (v vocabulary) correct(word string) (string, bool) {
+	maxDist := 1
+	if len(word) > 5 {
+		maxDist = 2
+	}
+
+	best, bestDist, bestCount := "", maxDist+1, 0
+	for candidate, count := range v {
+		if d := len(candidate) - len(word); d > maxDist || -d > maxDist {
+			continue
+		}
+		dist := editDistance(word, candidate, maxDist)
+		if dist > maxDist {
+			continue
+		}
+		if dist < bestDist || (dist == bestDist && (count > bestCount || (count == bestCount && candidate < best))) {
+			best, bestDist, bestCount = candidate, dist, count
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of neighbours each count
+// one. It gives up with max+1 once the distance must exceed max.
+func editDistance(a, b string, max int) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
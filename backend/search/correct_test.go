@@ -0,0 +1,82 @@
+package search
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"golang", "golang", 2, 0},
+		{"golang", "golan", 2, 1},   // deletion
+		{"golang", "golangs", 2, 1}, // insertion
+		{"golang", "gulang", 2, 1},  // substitution
+		{"golang", "oglang", 2, 1},  // swap of neighbours
+		{"handling", "handlnig", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"", "abc", 3, 3},
+		// gives up past max
+		{"kitten", "sitting", 1, 2},
+		{"abcdef", "uvwxyz", 2, 3},
+	} {
+		if got := editDistance(tc.a, tc.b, tc.max); got != tc.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tc.a, tc.b, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	v := vocabulary{}
+	v.add([]string{"handling", "handlers", "error", "errors", "golang"})
+	v.add([]string{"errors"})
+
+	for _, tc := range []struct {
+		word string
+		want string
+		ok   bool
+	}{
+		{"handlnig", "handling", true},
+		{"hnadling", "handling", true},
+		// a swap of neighbours is one edit
+		{"erorr", "error", true},
+		// short words allow one edit only
+		{"eror", "error", true},
+		{"ero", "", false},
+		{"hndlr", "", false},
+		// among equally close words, the more common one
+		{"errorz", "errors", true},
+		{"golnag", "golang", true},
+		{"kubernetes", "", false},
+	} {
+		got, ok := v.correct(tc.word)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("correct(%q) = %q, %v; want %q, %v", tc.word, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	v.remove([]string{"handling"})
+	if got, ok := v.correct("handlnig"); ok && got == "handling" {
+		t.Error("a removed word is still suggested")
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	ix := NewIndex(2, 160)
+	ix.Reset([]Document{{ID: 1, Title: "Error handling", Content: "Handling errors in golang"}})
+	for _, tc := range []struct {
+		raw, want string
+		ok        bool
+	}{
+		{`golnag "handlnig" -erorrs`, `golang "handling" -errors`, true},
+		{"golang handling", "golang handling", false},
+		// stems count as known words
+		{"handled", "handled", false},
+		{"สวัสดี", "สวัสดี", false},
+	} {
+		got, ok := ix.DidYouMean(tc.raw)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("DidYouMean(%q) = %q, %v; want %q, %v", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
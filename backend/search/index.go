@@ -0,0 +1,370 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BM25 parameters: k1 limits how much repeating a term helps, b how much
+// long posts are penalized
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Document is a post as the index sees it
+type Document struct {
+	ID        uint
+	Title     string
+	Content   string
+	Author    string
+	UserID    *uint
+	Tags      []string
+	CreatedAt time.Time
+}
+
+// Hit is one search result
+type Hit struct {
+	Document
+	Score float64
+	// TitleHTML and Snippet are HTML-escaped, with the matches in <mark>
+	TitleHTML string
+	Snippet   string
+}
+
+// Index is an inverted index over titles and contents, with a sorted list
+// of titles, tags and authors for suggestions and a vocabulary for spelling
+// corrections. It is safe for concurrent use; writers block searches only
+// while one post is updated.
+type Index struct {
+	titleBoost    float64
+	snippetLength int
+
+	mu       sync.RWMutex
+	docs     map[uint]*indexedDoc
+	postings map[string]map[uint]*posting
+	totalLen float64
+	suggest  *suggester
+	vocab    vocabulary
+}
+
+type indexedDoc struct {
+	Document
+	// length is the title length times the boost plus the content length
+	length float64
+	terms  []string
+	words  []string
+}
+
+// posting lists the positions of a term in one post
+type posting struct {
+	title, content []int
+}
+
+// NewIndex returns an empty index. A title match counts titleBoost times as
+// much as a content match; snippets are about snippetLength bytes.
+func NewIndex(titleBoost float64, snippetLength int) *Index {
+	return &Index{
+		titleBoost:    titleBoost,
+		snippetLength: snippetLength,
+		docs:          map[uint]*indexedDoc{},
+		postings:      map[string]map[uint]*posting{},
+		suggest:       newSuggester(nil),
+		vocab:         vocabulary{},
+	}
+}
+
+// Put adds the post, replacing an older version of it
+func (ix *Index) Put(doc Document) {
+	d := analyzeDoc(doc, ix.titleBoost)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(doc.ID)
+	ix.add(d)
+	for _, k := range suggestKeys(doc) {
+		ix.suggest.add(k)
+	}
+}
+
+// Remove drops the post; removing an unknown post does nothing
+func (ix *Index) Remove(id uint) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+// Reset replaces everything in the index with docs
+func (ix *Index) Reset(docs []Document) {
+	analyzed := make([]analyzedDoc, len(docs))
+	var keys []suggestKey
+	for i, doc := range docs {
+		analyzed[i] = analyzeDoc(doc, ix.titleBoost)
+		keys = append(keys, suggestKeys(doc)...)
+	}
+	// sorted once, rather than inserting post by post
+	suggest := newSuggester(keys)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.docs = make(map[uint]*indexedDoc, len(docs))
+	ix.postings = map[string]map[uint]*posting{}
+	ix.totalLen = 0
+	ix.suggest = suggest
+	ix.vocab = vocabulary{}
+	for _, d := range analyzed {
+		ix.add(d)
+	}
+}
+
+// Len returns the number of indexed posts
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// analyzedDoc is a post with its postings, analyzed before taking the lock
+type analyzedDoc struct {
+	doc      *indexedDoc
+	postings map[string]*posting
+}
+
+func analyzeDoc(doc Document, titleBoost float64) analyzedDoc {
+	postings := map[string]*posting{}
+	get := func(term string) *posting {
+		if postings[term] == nil {
+			postings[term] = &posting{}
+		}
+		return postings[term]
+	}
+
+	title, content := Analyze(doc.Title), Analyze(doc.Content)
+	for _, t := range title {
+		p := get(t.Term)
+		p.title = append(p.title, t.Pos)
+	}
+	for _, t := range content {
+		p := get(t.Term)
+		p.content = append(p.content, t.Pos)
+	}
+
+	d := &indexedDoc{
+		Document: doc,
+		length:   titleBoost*float64(len(title)) + float64(len(content)),
+		terms:    make([]string, 0, len(postings)),
+		words:    wordsOf(doc.Title, doc.Content),
+	}
+	for term := range postings {
+		d.terms = append(d.terms, term)
+	}
+	return analyzedDoc{doc: d, postings: postings}
+}
+
+// add indexes a post, except for its suggestions; the caller holds the
+// write lock
+func (ix *Index) add(a analyzedDoc) {
+	ix.docs[a.doc.ID] = a.doc
+	ix.totalLen += a.doc.length
+	ix.vocab.add(a.doc.words)
+
+	for term, p := range a.postings {
+		list := ix.postings[term]
+		if list == nil {
+			list = map[uint]*posting{}
+			ix.postings[term] = list
+		}
+		list[a.doc.ID] = p
+	}
+}
+
+// remove unindexes a post; the caller holds the write lock
+func (ix *Index) remove(id uint) {
+	d, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range d.terms {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.totalLen -= d.length
+	for _, k := range suggestKeys(d.Document) {
+		ix.suggest.remove(k)
+	}
+	ix.vocab.remove(d.words)
+	delete(ix.docs, id)
+}
+
+func suggestKeys(doc Document) []suggestKey {
+	keys := []suggestKey{
+		{SuggestTitle, doc.Title},
+		{SuggestAuthor, doc.Author},
+	}
+	for _, tag := range doc.Tags {
+		keys = append(keys, suggestKey{SuggestTag, tag})
+	}
+	return keys
+}
+
+// Suggest returns up to limit titles, tags and authors starting with
+// prefix; titles also match from the start of any of their words
+func (ix *Index) Suggest(prefix string, limit int) []Suggestion {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.suggest.complete(prefix, limit)
+}
+
+// DidYouMean corrects the English words of a query that no post contains,
+// keeping everything else (operators, quotes, Thai) as it is. ok is false
+// when there was nothing to correct.
+func (ix *Index) DidYouMean(raw string) (corrected string, ok bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	corrected = latinWord.ReplaceAllStringFunc(raw, func(word string) string {
+		lower := strings.ToLower(word)
+		if len(lower) < minCorrectLength || ix.vocab[lower] > 0 || len(ix.postings[Stem(lower)]) > 0 {
+			return word
+		}
+		if fix, found := ix.vocab.correct(lower); found {
+			ok = true
+			return fix
+		}
+		return word
+	})
+	return corrected, ok
+}
+
+// Search returns the page of hits starting at offset, best first, and the
+// total number of matching posts
+func (ix *Index) Search(q Query, offset, limit int) ([]Hit, int) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	terms := q.positive()
+	scores := map[uint]float64{}
+	for _, term := range terms {
+		list := ix.postings[term]
+		if len(list) == 0 {
+			continue
+		}
+		idf := ix.idf(len(list))
+		for id, p := range list {
+			scores[id] += idf * ix.bm25(p, ix.docs[id])
+		}
+	}
+
+	type scored struct {
+		doc   *indexedDoc
+		score float64
+	}
+	var matches []scored
+	for id, score := range scores {
+		if ix.matches(q, id) {
+			matches = append(matches, scored{ix.docs[id], score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].doc.CreatedAt.After(matches[j].doc.CreatedAt)
+	})
+
+	total := len(matches)
+	if offset >= total {
+		return []Hit{}, total
+	}
+	matches = matches[offset:min(offset+limit, total)]
+
+	highlight := map[string]bool{}
+	for _, term := range terms {
+		highlight[term] = true
+	}
+	hits := make([]Hit, len(matches))
+	for i, m := range matches {
+		hits[i] = Hit{
+			Document:  m.doc.Document,
+			Score:     m.score,
+			TitleHTML: Highlight(m.doc.Title, highlight, 0),
+			Snippet:   Highlight(m.doc.Content, highlight, ix.snippetLength),
+		}
+	}
+	return hits, total
+}
+
+// idf is the BM25 inverse document frequency of a term found in df posts
+func (ix *Index) idf(df int) float64 {
+	n := float64(len(ix.docs))
+	return math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// bm25 scores one term in one post, counting title matches titleBoost times
+func (ix *Index) bm25(p *posting, d *indexedDoc) float64 {
+	tf := ix.titleBoost*float64(len(p.title)) + float64(len(p.content))
+	avg := ix.totalLen / float64(len(ix.docs))
+	if avg == 0 {
+		avg = 1
+	}
+	return tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*d.length/avg))
+}
+
+// matches applies the phrase and exclusion operators to a post
+func (ix *Index) matches(q Query, id uint) bool {
+	if len(q.Terms) == 0 && len(q.Phrases) == 0 {
+		return false
+	}
+	for _, phrase := range q.Phrases {
+		if !ix.hasPhrase(phrase, id) {
+			return false
+		}
+	}
+	for _, phrase := range q.Exclude {
+		if ix.hasPhrase(phrase, id) {
+			return false
+		}
+	}
+	// without phrases a post needs one of the terms, which it has if it scored
+	return true
+}
+
+// hasPhrase reports whether the terms appear one after the other in the
+// title or in the content of the post
+func (ix *Index) hasPhrase(phrase []string, id uint) bool {
+	postings := make([]*posting, len(phrase))
+	for i, term := range phrase {
+		if postings[i] = ix.postings[term][id]; postings[i] == nil {
+			return false
+		}
+	}
+
+	field := func(p *posting, title bool) []int {
+		if title {
+			return p.title
+		}
+		return p.content
+	}
+	for _, title := range []bool{true, false} {
+	starts:
+		for _, start := range field(postings[0], title) {
+			for i := 1; i < len(phrase); i++ {
+				if !containsSorted(field(postings[i], title), start+i) {
+					continue starts
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func containsSorted(positions []int, pos int) bool {
+	i := sort.SearchInts(positions, pos)
+	return i < len(positions) && positions[i] == pos
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testIndex() *Index {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ix := NewIndex(2, 160)
+	ix.Reset([]Document{
+		{ID: 1, Title: "Error handling in Go", Content: "Wrap every error with context.", CreatedAt: day},
+		{ID: 2, Title: "Notes", Content: "An error here, an error there.", CreatedAt: day.Add(24 * time.Hour)},
+		{ID: 3, Title: "Notes", Content: "One error, then a panic.", CreatedAt: day.Add(48 * time.Hour)},
+		{ID: 4, Title: "Cooking", Content: "Nothing to see.", CreatedAt: day.Add(72 * time.Hour)},
+		{ID: 5, Title: "Notes", Content: "One error, then a panic.", CreatedAt: day.Add(96 * time.Hour)},
+		{ID: 6, Title: "ภาษาไทย", Content: "สวัสดีครับ ยินดีต้อนรับ", CreatedAt: day},
+	})
+	return ix
+}
+
+func search(t *testing.T, ix *Index, raw string) []uint {
+	t.Helper()
+	q, err := ParseQuery(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits, total := ix.Search(q, 0, 10)
+	if total != len(hits) {
+		t.Errorf("%q: total %d for %d hits", raw, total, len(hits))
+	}
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := testIndex()
+	for _, tc := range []struct {
+		raw  string
+		want []uint
+	}{
+		// a title match beats repetition, which beats a single mention;
+		// equal scores go newest first
+		{"error", []uint{1, 2, 5, 3}},
+		{`"error handling"`, []uint{1}},
+		{"error -panic", []uint{1, 2}},
+		{`error -"then a panic"`, []uint{1, 2}},
+		{"cooking", []uint{4}},
+		{"nothing", []uint{4}},
+		{"missing", []uint{}},
+		{"สวัสดี", []uint{6}},
+		{"ยินดี", []uint{6}},
+	} {
+		if got := search(t, ix, tc.raw); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	ix := testIndex()
+	q, _ := ParseQuery("error")
+	hits, total := ix.Search(q, 1, 2)
+	if total != 4 || len(hits) != 2 || hits[0].ID != 2 || hits[1].ID != 5 {
+		t.Errorf("second page of two: %d of %d hits, %+v", len(hits), total, hits)
+	}
+	if hits, total := ix.Search(q, 10, 2); total != 4 || len(hits) != 0 {
+		t.Errorf("past the end: %d of %d hits", len(hits), total)
+	}
+}
+
+func TestSearchUpdates(t *testing.T) {
+	ix := testIndex()
+	ix.Remove(1)
+	ix.Put(Document{ID: 4, Title: "Cooking errors", Content: "Burnt it."})
+	if got := search(t, ix, "error"); !reflect.DeepEqual(got, []uint{4, 2, 5, 3}) {
+		t.Errorf("after a removal and an edit: %v", got)
+	}
+	if got := search(t, ix, "nothing"); len(got) != 0 {
+		t.Errorf("the old version of an edited post is still found: %v", got)
+	}
+	if ix.Len() != 5 {
+		t.Errorf("Len = %d, want 5", ix.Len())
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	ix := testIndex()
+	q, _ := ParseQuery("handling")
+	hits, _ := ix.Search(q, 0, 10)
+	if len(hits) != 1 || hits[0].TitleHTML != "Error <mark>handling</mark> in Go" {
+		t.Errorf("hits = %+v", hits)
+	}
+}
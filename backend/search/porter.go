@@ -0,0 +1,254 @@
+package search
+
+// Stem reduces an English word to its stem with the Porter algorithm
+// (M.F. Porter, "An algorithm for suffix stripping", 1980), so that
+// "connected", "connecting" and "connection" all index as "connect".
+// word must be lower case ASCII.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	s := &stemmer{b: []byte(word), k: len(word) - 1}
+	s.step1ab()
+	if s.k > 1 {
+		s.step1c()
+		s.step2()
+		s.step3()
+		s.step4()
+		s.step5()
+	}
+	return string(s.b[:s.k+1])
+}
+
+// stemmer works on b[0..k]; j marks the end of the stem in front of the
+// suffix matched by the last successful ends
+type stemmer struct {
+	b    []byte
+	k, j int
+}
+
+// cons reports whether b[i] is a consonant
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// m measures the number of consonant sequences in b[0..j]:
+// <c><v> gives 0, <c>vc<v> gives 1, <c>vcvc<v> gives 2, ...
+func (s *stemmer) m() int {
+	n, i := 0, 0
+	for {
+		if i > s.j {
+			return n
+		}
+		if !s.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > s.j {
+				return n
+			}
+			if s.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > s.j {
+				return n
+			}
+			if !s.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem reports whether b[0..j] contains a vowel
+func (s *stemmer) vowelInStem() bool {
+	for i := 0; i <= s.j; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleC reports whether b[i-1..i] is a double consonant
+func (s *stemmer) doubleC(i int) bool {
+	return i >= 1 && s.b[i] == s.b[i-1] && s.cons(i)
+}
+
+// cvc reports whether b[i-2..i] is consonant-vowel-consonant and the last
+// consonant is not w, x or y, as in "hop" but not "snow"
+func (s *stemmer) cvc(i int) bool {
+	if i < 2 || !s.cons(i) || s.cons(i-1) || !s.cons(i-2) {
+		return false
+	}
+	switch s.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends reports whether b[0..k] ends with suffix, and if so sets j in front of it
+func (s *stemmer) ends(suffix string) bool {
+	n := len(suffix)
+	if n > s.k+1 || string(s.b[s.k-n+1:s.k+1]) != suffix {
+		return false
+	}
+	s.j = s.k - n
+	return true
+}
+
+// setTo replaces b[j+1..k] with replacement
+func (s *stemmer) setTo(replacement string) {
+	s.b = append(s.b[:s.j+1], replacement...)
+	s.k = s.j + len(replacement)
+}
+
+// replace is setTo, if the stem has at least one consonant sequence
+func (s *stemmer) replace(replacement string) {
+	if s.m() > 0 {
+		s.setTo(replacement)
+	}
+}
+
+// step1ab removes plurals and -ed or -ing:
+// caresses → caress, ponies → poni, meeting → meet, hopping → hop
+func (s *stemmer) step1ab() {
+	if s.b[s.k] == 's' {
+		switch {
+		case s.ends("sses"):
+			s.k -= 2
+		case s.ends("ies"):
+			s.setTo("i")
+		case s.b[s.k-1] != 's':
+			s.k--
+		}
+	}
+
+	if s.ends("eed") {
+		if s.m() > 0 {
+			s.k--
+		}
+		return
+	}
+	if (s.ends("ed") || s.ends("ing")) && s.vowelInStem() {
+		s.k = s.j
+		switch {
+		case s.ends("at"):
+			s.setTo("ate")
+		case s.ends("bl"):
+			s.setTo("ble")
+		case s.ends("iz"):
+			s.setTo("ize")
+		case s.doubleC(s.k):
+			s.k--
+			switch s.b[s.k] {
+			case 'l', 's', 'z':
+				s.k++
+			}
+		default:
+			s.j = s.k
+			if s.m() == 1 && s.cvc(s.k) {
+				s.setTo("e")
+			}
+		}
+	}
+}
+
+// step1c turns a terminal y into i when there is another vowel in the stem
+func (s *stemmer) step1c() {
+	if s.ends("y") && s.vowelInStem() {
+		s.b[s.k] = 'i'
+	}
+}
+
+// suffixRule replaces a suffix; rules are tried in order and the first
+// matching suffix ends the step, whether or not it was replaced
+type suffixRule struct {
+	suffix, replacement string
+}
+
+func (s *stemmer) applyRules(rules []suffixRule) {
+	for _, r := range rules {
+		if s.ends(r.suffix) {
+			s.replace(r.replacement)
+			return
+		}
+	}
+}
+
+// step2 maps double suffixes to single ones: -ization → -ize, ...
+func (s *stemmer) step2() {
+	s.applyRules([]suffixRule{
+		{"ational", "ate"}, {"tional", "tion"},
+		{"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"},
+		{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+		{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"},
+		{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"},
+		{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+		{"logi", "log"},
+	})
+}
+
+// step3 deals with -ic-, -full, -ness etc.
+func (s *stemmer) step3() {
+	s.applyRules([]suffixRule{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"},
+		{"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""},
+		{"ness", ""},
+	})
+}
+
+// step4 removes -ant, -ence etc. from stems with more than one consonant sequence
+func (s *stemmer) step4() {
+	suffixes := []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+		"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	}
+	for _, suffix := range suffixes {
+		if !s.ends(suffix) {
+			continue
+		}
+		// -ion only goes after s or t
+		if suffix == "ion" && (s.j < 0 || (s.b[s.j] != 's' && s.b[s.j] != 't')) {
+			continue
+		}
+		if s.m() > 1 {
+			s.k = s.j
+		}
+		return
+	}
+}
+
+// step5 removes a final -e and turns -ll into -l on longer stems
+func (s *stemmer) step5() {
+	s.j = s.k
+	if s.b[s.k] == 'e' {
+		a := s.m()
+		if a > 1 || (a == 1 && !s.cvc(s.k-1)) {
+			s.k--
+		}
+	}
+	if s.b[s.k] == 'l' && s.doubleC(s.k) && s.m() > 1 {
+		s.k--
+	}
+}
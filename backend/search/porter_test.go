@@ -0,0 +1,53 @@
+package search
+
+import "testing"
+
+// Examples from M.F. Porter's paper and the reference vocabulary
+func TestStem(t *testing.T) {
+	for word, want := range map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"ties":           "ti",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"conflated":      "conflat",
+		"troubled":       "troubl",
+		"sized":          "size",
+		"hopping":        "hop",
+		"tanned":         "tan",
+		"falling":        "fall",
+		"hissing":        "hiss",
+		"fizzed":         "fizz",
+		"failing":        "fail",
+		"filing":         "file",
+		"happy":          "happi",
+		"sky":            "sky",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"rational":       "ration",
+		"digitizer":      "digit",
+		"generalization": "gener",
+		"oscillators":    "oscil",
+		"electrical":     "electr",
+		"hopefulness":    "hope",
+		"adjustment":     "adjust",
+		"controlling":    "control",
+		"rolling":        "roll",
+		"probate":        "probat",
+		"rate":           "rate",
+		"cease":          "ceas",
+		"connected":      "connect",
+		"connecting":     "connect",
+		"connection":     "connect",
+		"go":             "go",
+		"is":             "is",
+	} {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
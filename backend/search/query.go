@@ -0,0 +1,91 @@
+package search
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrEmptyQuery is returned for a query without anything to look for
+var ErrEmptyQuery = errors.New("query has no search terms")
+
+// Query is a parsed search. Plain words rank posts, "quoted phrases" must
+// appear, and -word or -"phrase" must not.
+type Query struct {
+	// Terms are optional: a post needs at least one of them, or a phrase
+	Terms []string
+	// Phrases must all appear, word for word
+	Phrases [][]string
+	// Exclude are terms or phrases that rule a post out
+	Exclude [][]string
+}
+
+// ParseQuery parses a search box query such as
+//
+//	go "error handling" -panic
+//
+// A word that analyzes into several terms, such as a Thai word or "e-mail",
+// is matched as a phrase.
+func ParseQuery(raw string) (Query, error) {
+	var q Query
+	for rest := strings.TrimSpace(raw); rest != ""; rest = strings.TrimSpace(rest) {
+		exclude := false
+		if len(rest) > 1 && rest[0] == '-' && rest[1] != ' ' {
+			exclude = true
+			rest = rest[1:]
+		}
+
+		var text string
+		quoted := rest[0] == '"'
+		if quoted {
+			rest = rest[1:]
+			end := strings.IndexByte(rest, '"')
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], strings.TrimPrefix(rest[end:], `"`)
+		} else {
+			end := strings.IndexAny(rest, " \t\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+
+		terms := Terms(text)
+		switch {
+		case len(terms) == 0:
+		case exclude:
+			q.Exclude = append(q.Exclude, terms)
+		case len(terms) == 1:
+			q.Terms = append(q.Terms, terms[0])
+		default:
+			q.Phrases = append(q.Phrases, terms)
+		}
+	}
+
+	if len(q.Terms) == 0 && len(q.Phrases) == 0 {
+		return q, ErrEmptyQuery
+	}
+	return q, nil
+}
+
+// positive returns every term that counts towards the ranking, once
+func (q Query) positive() []string {
+	seen := map[string]bool{}
+	var terms []string
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	for _, t := range q.Terms {
+		add(t)
+	}
+	for _, phrase := range q.Phrases {
+		for _, t := range phrase {
+			add(t)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want Query
+	}{
+		{`go`, Query{Terms: []string{"go"}}},
+		{`go "error handling" -panic`, Query{
+			Terms:   []string{"go"},
+			Phrases: [][]string{{"error", "handl"}},
+			Exclude: [][]string{{"panic"}},
+		}},
+		{`-"foo bar" baz`, Query{Terms: []string{"baz"}, Exclude: [][]string{{"foo", "bar"}}}},
+		// a lone dash is not an operator
+		{`- go`, Query{Terms: []string{"go"}}},
+		// an unterminated quote runs to the end
+		{`"error handling`, Query{Phrases: [][]string{{"error", "handl"}}}},
+		// a word of several terms is a phrase
+		{`e-mail`, Query{Phrases: [][]string{{"e", "mail"}}}},
+		{`สวัสดี`, Query{Phrases: [][]string{{"สวั", "วัส", "สดี"}}}},
+	} {
+		got, err := ParseQuery(tc.raw)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tc.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseQuery(%q) = %#v, want %#v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestParseQueryEmpty(t *testing.T) {
+	for _, raw := range []string{"", "   ", "!!!", "-only", `-"only this"`} {
+		if _, err := ParseQuery(raw); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("ParseQuery(%q) = %v, want ErrEmptyQuery", raw, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+	ellipsis  = "…"
+)
+
+// Highlight HTML-escapes text and wraps the words whose terms are in terms
+// in <mark>. With maxLen > 0 only the window of about maxLen bytes with the
+// most matches is kept, cut at spaces where possible.
+func Highlight(text string, terms map[string]bool, maxLen int) string {
+	// byte ranges to mark, merged where Thai pairs overlap
+	var ranges [][2]int
+	for _, t := range Analyze(text) {
+		if !terms[t.Term] {
+			continue
+		}
+		if n := len(ranges); n > 0 && t.Start <= ranges[n-1][1] {
+			ranges[n-1][1] = max(ranges[n-1][1], t.End)
+			continue
+		}
+		ranges = append(ranges, [2]int{t.Start, t.End})
+	}
+
+	start, end := 0, len(text)
+	if maxLen > 0 && len(text) > maxLen {
+		start, end = window(text, ranges, maxLen)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+	pos := start
+	for _, r := range ranges {
+		if r[1] <= start || r[0] >= end {
+			continue
+		}
+		from, to := max(r[0], start), min(r[1], end)
+		b.WriteString(html.EscapeString(text[pos:from]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[from:to]))
+		b.WriteString(markClose)
+		pos = to
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+// window picks the maxLen bytes of text holding the most marked ranges,
+// starting a little before the first of them
+func window(text string, ranges [][2]int, maxLen int) (int, int) {
+	start, best := 0, 0
+	for i, r := range ranges {
+		count := 0
+		for _, next := range ranges[i:] {
+			if next[1]-r[0] > maxLen*3/4 {
+				break
+			}
+			count++
+		}
+		if count > best {
+			start, best = max(0, r[0]-maxLen/4), count
+		}
+	}
+
+	end := min(len(text), start+maxLen)
+	if end == len(text) {
+		start = max(0, end-maxLen)
+	}
+	return cutAtSpace(text, start, true), cutAtSpace(text, end, false)
+}
+
+// cutAtSpace moves a cut to a nearby space, or at least to a character
+// boundary; starts move forward and ends move back
+func cutAtSpace(text string, i int, forward bool) int {
+	if i <= 0 || i >= len(text) {
+		return i
+	}
+	const reach = 20
+	if forward {
+		if j := strings.IndexByte(text[i:min(len(text), i+reach)], ' '); j >= 0 {
+			return i + j + 1
+		}
+		for i < len(text) && !utf8.RuneStart(text[i]) {
+			i++
+		}
+		return i
+	}
+	if j := strings.LastIndexByte(text[max(0, i-reach):i], ' '); j >= 0 {
+		return max(0, i-reach) + j
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
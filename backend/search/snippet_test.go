@@ -0,0 +1,44 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHighlight(t *testing.T) {
+	thai := map[string]bool{"สวั": true, "วัส": true, "สดี": true}
+	for _, tc := range []struct {
+		name  string
+		text  string
+		terms map[string]bool
+		max   int
+		want  string
+	}{
+		{"escapes and marks", "Errors & <panics> in Go: handling errors well", map[string]bool{"error": true}, 0,
+			"<mark>Errors</mark> &amp; &lt;panics&gt; in Go: handling <mark>errors</mark> well"},
+		{"no match", "nothing here", map[string]bool{"error": true}, 0, "nothing here"},
+		{"thai pairs merge", "ผมชอบสวัสดีมาก", thai, 0, "ผมชอบ<mark>สวัสดี</mark>มาก"},
+		{"window around the match",
+			"aaaa bbbb cccc dddd eeee ffff gggg hhhh iiii jjjj kkkk target llll mmmm nnnn oooo pppp qqqq",
+			map[string]bool{"target": true}, 30, "…kkkk <mark>target</mark> llll mmmm nnnn…"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Highlight(tc.text, tc.terms, tc.max); got != tc.want {
+				t.Errorf("Highlight = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHighlightCutsAtCharacters(t *testing.T) {
+	// no spaces to cut at, and every character is several bytes
+	text := strings.Repeat("สวัสดี", 20) + "ครับ" + strings.Repeat("สวัสดี", 20)
+	got := Highlight(text, map[string]bool{"ครั": true}, 60)
+	if !utf8.ValidString(got) {
+		t.Errorf("the snippet cuts a character in half: %q", got)
+	}
+	if !strings.HasPrefix(got, ellipsis) || !strings.HasSuffix(got, ellipsis) || !strings.Contains(got, "<mark>ครั</mark>") {
+		t.Errorf("snippet = %q, want the match with ellipses on both sides", got)
+	}
+}
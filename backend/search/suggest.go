@@ -0,0 +1,152 @@
+package search
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kinds of suggestion
+const (
+	SuggestTitle  = "title"
+	SuggestTag    = "tag"
+	SuggestAuthor = "author"
+)
+
+// maxSuggestScan bounds how many entries one prefix lookup collects
+const maxSuggestScan = 500
+
+// Suggestion is one completion of a prefix
+type Suggestion struct {
+	Text string `json:"text"`
+	Kind string `json:"kind"`
+	// Posts is how many published posts have this title, tag or author
+	Posts int `json:"posts"`
+}
+
+type suggestKey struct {
+	kind, text string
+}
+
+// suggester finds the titles, tags and authors starting with a prefix.
+// Titles are also found from the start of each of their words, so "han"
+// completes "Error handling in Go".
+//
+// Every place an entry can be found from is one element of a slice sorted
+// by its lower-cased text, searched by binary search. The texts are
+// substrings of one lower-cased copy of the entry, so a title costs one
+// small element per word rather than a node per character.
+type suggester struct {
+	counts  map[suggestKey]int
+	entries []suggestEntry
+}
+
+type suggestEntry struct {
+	// path is the lower-cased entry from one of its word starts on
+	path string
+	key  suggestKey
+}
+
+// newSuggester builds a suggester of keys, which may repeat: each time
+// counts one post
+func newSuggester(keys []suggestKey) *suggester {
+	s := &suggester{counts: map[suggestKey]int{}}
+	for _, k := range keys {
+		if k.text == "" {
+			continue
+		}
+		if s.counts[k]++; s.counts[k] == 1 {
+			for _, path := range paths(k) {
+				s.entries = append(s.entries, suggestEntry{path, k})
+			}
+		}
+	}
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].path < s.entries[j].path })
+	return s
+}
+
+// add counts one more post for the entry
+func (s *suggester) add(k suggestKey) {
+	if k.text == "" {
+		return
+	}
+	if s.counts[k]++; s.counts[k] > 1 {
+		return
+	}
+	for _, path := range paths(k) {
+		s.entries = slices.Insert(s.entries, s.search(path), suggestEntry{path, k})
+	}
+}
+
+// remove counts one post less for the entry, dropping it at zero
+func (s *suggester) remove(k suggestKey) {
+	if s.counts[k] == 0 {
+		return
+	}
+	if s.counts[k]--; s.counts[k] > 0 {
+		return
+	}
+	delete(s.counts, k)
+	for _, path := range paths(k) {
+		for i := s.search(path); i < len(s.entries) && s.entries[i].path == path; i++ {
+			if s.entries[i].key == k {
+				s.entries = slices.Delete(s.entries, i, i+1)
+				break
+			}
+		}
+	}
+}
+
+// search returns the index of the first entry whose path is at least path
+func (s *suggester) search(path string) int {
+	return sort.Search(len(s.entries), func(i int) bool { return s.entries[i].path >= path })
+}
+
+// paths returns the texts the entry is found from
+func paths(k suggestKey) []string {
+	text := strings.ToLower(k.text)
+	if k.kind != SuggestTitle {
+		return []string{text}
+	}
+	paths := []string{text}
+	for i, r := range text {
+		if i > 0 && unicode.IsSpace(r) {
+			if rest := strings.TrimLeftFunc(text[i:], unicode.IsSpace); rest != "" {
+				paths = append(paths, rest)
+			}
+		}
+	}
+	return paths
+}
+
+// complete returns up to limit entries under prefix, most used first
+func (s *suggester) complete(prefix string, limit int) []Suggestion {
+	prefix = strings.ToLower(prefix)
+	found := map[suggestKey]bool{}
+	for i := s.search(prefix); i < len(s.entries) && len(found) < maxSuggestScan; i++ {
+		if !strings.HasPrefix(s.entries[i].path, prefix) {
+			break
+		}
+		found[s.entries[i].key] = true
+	}
+
+	suggestions := make([]Suggestion, 0, len(found))
+	for k := range found {
+		suggestions = append(suggestions, Suggestion{Text: k.text, Kind: k.kind, Posts: s.counts[k]})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Posts != b.Posts {
+			return a.Posts > b.Posts
+		}
+		if len(a.Text) != len(b.Text) {
+			return len(a.Text) < len(b.Text)
+		}
+		return a.Text < b.Text
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
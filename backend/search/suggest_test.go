@@ -0,0 +1,93 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func texts(suggestions []Suggestion) []string {
+	out := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		out[i] = s.Kind + ":" + s.Text
+	}
+	return out
+}
+
+func TestSuggest(t *testing.T) {
+	ix := NewIndex(2, 160)
+	ix.Reset([]Document{
+		{ID: 1, Title: "Error handling in Go", Author: "hana", Tags: []string{"go", "errors"}},
+		{ID: 2, Title: "Handlers and middleware", Author: "hana", Tags: []string{"go"}},
+		{ID: 3, Title: "Gophers", Author: "bob", Tags: []string{"go"}},
+	})
+
+	for _, tc := range []struct {
+		prefix string
+		want   []string
+	}{
+		// most posts first, then shorter
+		{"go", []string{"tag:go", "title:Gophers", "title:Error handling in Go"}},
+		// titles from any word start, case-insensitively
+		{"HAN", []string{"author:hana", "title:Error handling in Go", "title:Handlers and middleware"}},
+		{"middle", []string{"title:Handlers and middleware"}},
+		{"in go", []string{"title:Error handling in Go"}},
+		{"xyz", []string{}},
+	} {
+		if got := texts(ix.Suggest(tc.prefix, 10)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Suggest(%q) = %q, want %q", tc.prefix, got, tc.want)
+		}
+	}
+	if got := ix.Suggest("go", 10); got[0].Posts != 3 {
+		t.Errorf("tag go counts %d posts, want 3", got[0].Posts)
+	}
+	if got := ix.Suggest("han", 1); len(got) != 1 {
+		t.Errorf("limit 1 returned %d suggestions", len(got))
+	}
+
+	// entries go when their last post does
+	ix.Remove(1)
+	if got := texts(ix.Suggest("han", 10)); !reflect.DeepEqual(got, []string{"author:hana", "title:Handlers and middleware"}) {
+		t.Errorf("after removing a post: %q", got)
+	}
+	ix.Put(Document{ID: 2, Title: "Routers", Author: "bob"})
+	if got := texts(ix.Suggest("han", 10)); len(got) != 0 {
+		t.Errorf("after editing the last post: %q", got)
+	}
+	if got := ix.Suggest("go", 10); len(got) != 2 || got[0].Posts != 1 {
+		t.Errorf("after edits: %+v", got)
+	}
+}
+
+// Titles may be 200 characters long: the suggestions must not cost memory
+// per character of them
+func TestSuggestMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a large index")
+	}
+	keys := make([]suggestKey, 10000)
+	for i := range keys {
+		words := make([]string, 20)
+		for w := range words {
+			words[w] = fmt.Sprintf("w%02dx%05d", w, i)
+		}
+		keys[i] = suggestKey{SuggestTitle, strings.Join(words, " ")[:190]}
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	s := newSuggester(keys)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	const limit = 32 << 20
+	if used := after.HeapAlloc - before.HeapAlloc; used > limit {
+		t.Errorf("10,000 long titles take %d MB, want at most %d", used>>20, limit>>20)
+	}
+	if got := s.complete("w07x04242", 5); len(got) != 1 {
+		t.Errorf("complete in the large index: %+v", got)
+	}
+}
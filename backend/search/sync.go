@@ -0,0 +1,232 @@
+package search
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"blog-app-backend/models"
+)
+
+// syncDelay batches the changes of a burst of writes, and gives the
+// transaction a change was made in time to commit before it is read back
+const syncDelay = 250 * time.Millisecond
+
+// Syncer keeps an Index in step with the published posts. As a gorm plugin
+// it notices every write to the posts table: a post saved or deleted by
+// primary key is re-read and re-indexed on its own, while a bulk change
+// (e.g. the account purger reassigning posts) rebuilds the whole index.
+// A periodic rebuild catches anything missed.
+type Syncer struct {
+	db              *gorm.DB
+	index           *Index
+	rebuildInterval time.Duration
+	log             *slog.Logger
+
+	mu      sync.Mutex
+	pending map[uint]bool
+	rebuild bool
+	wake    chan struct{}
+}
+
+func NewSyncer(db *gorm.DB, index *Index, rebuildInterval time.Duration, logger *slog.Logger) *Syncer {
+	return &Syncer{
+		db:              db,
+		index:           index,
+		rebuildInterval: rebuildInterval,
+		log:             logger,
+		pending:         map[uint]bool{},
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+func (s *Syncer) Name() string { return "search" }
+
+// Initialize registers the callbacks that record changed posts
+func (s *Syncer) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	const after = "gorm:commit_or_rollback_transaction"
+	if err := cb.Create().After(after).Register("search:after_create", s.recordChange); err != nil {
+		return err
+	}
+	if err := cb.Update().After(after).Register("search:after_update", s.recordChange); err != nil {
+		return err
+	}
+	return cb.Delete().After(after).Register("search:after_delete", s.recordChange)
+}
+
+var postType = reflect.TypeOf(models.Post{})
+
+func (s *Syncer) recordChange(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.ModelType != postType {
+		return
+	}
+
+	ids := postIDs(db.Statement.ReflectValue)
+	s.mu.Lock()
+	if len(ids) == 0 {
+		s.rebuild = true
+	}
+	for _, id := range ids {
+		s.pending[id] = true
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// postIDs returns the primary keys of the posts a statement was run on, or
+// nil when it was run on a condition instead
+func postIDs(v reflect.Value) []uint {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if post, ok := v.Interface().(models.Post); ok && post.ID != 0 {
+			return []uint{post.ID}
+		}
+	case reflect.Slice, reflect.Array:
+		var ids []uint
+		for i := 0; i < v.Len(); i++ {
+			more := postIDs(v.Index(i))
+			if len(more) == 0 {
+				return nil
+			}
+			ids = append(ids, more...)
+		}
+		return ids
+	}
+	return nil
+}
+
+// Start builds the index, then applies changes as they are recorded and
+// rebuilds it every rebuild interval, until the returned function is called
+func (s *Syncer) Start() (stop func()) {
+	if err := s.Rebuild(); err != nil {
+		s.log.Error("building search index", "error", err)
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	ticker := time.NewTicker(s.rebuildInterval)
+
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.wake:
+				time.Sleep(syncDelay)
+				if err := s.flush(); err != nil {
+					s.log.Error("updating search index", "error", err)
+				}
+			case <-ticker.C:
+				if err := s.Rebuild(); err != nil {
+					s.log.Error("rebuilding search index", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// flush re-indexes the posts changed since the last flush
+func (s *Syncer) flush() error {
+	s.mu.Lock()
+	ids := make([]uint, 0, len(s.pending))
+	for id := range s.pending {
+		ids = append(ids, id)
+	}
+	rebuild := s.rebuild
+	s.pending, s.rebuild = map[uint]bool{}, false
+	s.mu.Unlock()
+
+	if rebuild {
+		return s.Rebuild()
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var posts []models.Post
+	if err := s.published().Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		// try again with the next change or rebuild
+		s.mu.Lock()
+		for _, id := range ids {
+			s.pending[id] = true
+		}
+		s.mu.Unlock()
+		return err
+	}
+
+	found := map[uint]bool{}
+	for _, post := range posts {
+		s.index.Put(documentOf(post))
+		found[post.ID] = true
+	}
+	// deleted or unpublished
+	for _, id := range ids {
+		if !found[id] {
+			s.index.Remove(id)
+		}
+	}
+	return nil
+}
+
+// Rebuild re-reads every published post into the index
+func (s *Syncer) Rebuild() error {
+	var docs []Document
+	var batch []models.Post
+	err := s.published().FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, post := range batch {
+			docs = append(docs, documentOf(post))
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	s.index.Reset(docs)
+	s.log.Debug("search index rebuilt", "posts", len(docs))
+	return nil
+}
+
+// published reads from the primary, which has every change already
+func (s *Syncer) published() *gorm.DB {
+	return s.db.Clauses(dbresolver.Write).Model(&models.Post{}).Preload("Tags").Where("published = ?", true)
+}
+
+func documentOf(post models.Post) Document {
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = tag.Name
+	}
+	return Document{
+		ID:        post.ID,
+		Title:     post.Title,
+		Content:   post.Content,
+		Author:    post.Author,
+		UserID:    post.UserID,
+		Tags:      tags,
+		CreatedAt: post.CreatedAt,
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blog-app-backend/models"
+)
+
+// What happens to a deleted account's posts
+const (
+	PostsActionDelete    = "delete"    // remove the posts
+	PostsActionAnonymize = "anonymize" // keep them, credited to "Deleted user"
+	PostsActionReassign  = "reassign"  // hand them over to another user
+)
+
+// AccountPurger carries out account deletions once their grace period has ended
+type AccountPurger struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewAccountPurger(db *gorm.DB, logger *slog.Logger) *AccountPurger {
+	return &AccountPurger{db: db, log: logger}
+}
+
+// Start purges due accounts now and then every interval, until the
+// returned function is called. stop waits for a running purge to finish.
+func (p *AccountPurger) Start(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for {
+			if err := p.PurgeDue(); err != nil {
+				p.log.Error("account purger failed", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// PurgeDue purges every account scheduled for deletion before now
+func (p *AccountPurger) PurgeDue() error {
+	var users []models.User
+	if err := p.db.Where("deletion_scheduled_at <= ?", time.Now()).Find(&users).Error; err != nil {
+		return err
+	}
+
+	// one account that can't be purged must not hold up the others
+	var errs []error
+	for _, user := range users {
+		if err := p.Purge(user); err != nil {
+			p.log.Error("account purge failed", "user_id", user.ID, "error", err)
+			errs = append(errs, fmt.Errorf("user %d: %v", user.ID, err))
+			continue
+		}
+		p.log.Info("account purged", "user_id", user.ID)
+	}
+	return errors.Join(errs...)
+}
+
+// Purge removes or anonymizes everything that identifies the user
+func (p *AccountPurger) Purge(user models.User) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		// 1) posts, as chosen by the user; the same posts as in their export
+		posts := tx.Model(&models.Post{}).Scopes(models.PostsOf(user))
+		switch user.DeletionPostsAction {
+		case PostsActionDelete:
+			// post_tags and comments have no foreign keys to cascade from the posts
+			ids := tx.Unscoped().Model(&models.Post{}).Scopes(models.PostsOf(user)).Select("id")
+			if err := tx.Exec("DELETE FROM post_tags WHERE post_id IN (?)", ids).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM comments WHERE post_id IN (?)", ids).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Scopes(models.PostsOf(user)).Delete(&models.Post{}).Error; err != nil {
+				return err
+			}
+		case PostsActionReassign:
+			var heir models.User
+			if user.DeletionReassignTo == nil || tx.First(&heir, *user.DeletionReassignTo).Error != nil {
+				return fmt.Errorf("reassign target no longer exists")
+			}
+			if err := posts.Updates(map[string]interface{}{"user_id": heir.ID, "author": heir.Username}).Error; err != nil {
+				return err
+			}
+		default:
+			if err := posts.Updates(map[string]interface{}{"user_id": nil, "author": "Deleted user"}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 2) the user's comments, recounting the posts they were on
+		var commented []uint
+		if err := tx.Model(&models.Comment{}).Where("user_id = ?", user.ID).Distinct().Pluck("post_id", &commented).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if len(commented) > 0 {
+			if err := tx.Exec(`UPDATE posts SET comment_count = (
+				SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL
+			) WHERE id IN ?`, commented).Error; err != nil {
+				return err
+			}
+		}
+
+		// 3) sessions and linked identities
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+
+		// 4) anonymize personal fields, then soft-delete the row
+		placeholder := fmt.Sprintf("deleted-%d", user.ID)
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"username":              placeholder,
+			"email":                 placeholder + "@deleted.invalid",
+			"password":              "",
+			"full_name":             "",
+			"avatar":                "",
+			"is_active":             false,
+			"deletion_scheduled_at": nil,
+			"deletion_posts_action": "",
+			"deletion_reassign_to":  nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blog-app-backend/config"
+	"blog-app-backend/migrations"
+	"blog-app-backend/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := config.OpenDB(config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "blog.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := migrations.New(db, discardLogger()).Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// scheduleDeletion creates a user whose deletion is due, with one linked
+// and one legacy post
+func scheduleDeletion(t *testing.T, db *gorm.DB, username, action string, reassignTo *uint) models.User {
+	t.Helper()
+	due := time.Now().Add(-time.Minute)
+	user := models.User{
+		Username:            username,
+		Email:               username + "@example.com",
+		Password:            "x",
+		IsActive:            true,
+		DeletionScheduledAt: &due,
+		DeletionPostsAction: action,
+		DeletionReassignTo:  reassignTo,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	posts := []models.Post{
+		{Title: "linked", Author: username, UserID: &user.ID, Published: true},
+		{Title: "legacy", Author: username, Published: true},
+	}
+	if err := db.Create(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestPurgeLeavesLegacyPosts(t *testing.T) {
+	db := newTestDB(t)
+	heir := models.User{Username: "heir", Email: "heir@example.com", Password: "x", IsActive: true}
+	if err := db.Create(&heir).Error; err != nil {
+		t.Fatal(err)
+	}
+	scheduleDeletion(t, db, "anna", PostsActionAnonymize, nil)
+	scheduleDeletion(t, db, "dora", PostsActionDelete, nil)
+	scheduleDeletion(t, db, "rita", PostsActionReassign, &heir.ID)
+
+	if err := NewAccountPurger(db, discardLogger()).PurgeDue(); err != nil {
+		t.Fatalf("PurgeDue: %v", err)
+	}
+
+	// anyone could have written a legacy post under these names
+	for _, name := range []string{"anna", "dora", "rita"} {
+		var legacy int64
+		db.Model(&models.Post{}).Where("author = ? AND user_id IS NULL AND title = ?", name, "legacy").Count(&legacy)
+		if legacy != 1 {
+			t.Errorf("the legacy post under %s was changed by the purge", name)
+		}
+	}
+	var anonymized, reassigned, left int64
+	db.Model(&models.Post{}).Where("author = ? AND user_id IS NULL", "Deleted user").Count(&anonymized)
+	db.Model(&models.Post{}).Where("author = ? AND user_id = ?", "heir", heir.ID).Count(&reassigned)
+	db.Unscoped().Model(&models.Post{}).Count(&left)
+	if anonymized != 1 || reassigned != 1 || left != 5 {
+		t.Errorf("anonymized %d, reassigned %d, left %d posts; want 1, 1 and 5", anonymized, reassigned, left)
+	}
+}
+
+func TestPurgeDueContinuesPastFailures(t *testing.T) {
+	db := newTestDB(t)
+	gone := uint(9999)
+	stuck := scheduleDeletion(t, db, "stuck", PostsActionReassign, &gone)
+	other := scheduleDeletion(t, db, "other", PostsActionAnonymize, nil)
+
+	err := NewAccountPurger(db, discardLogger()).PurgeDue()
+	if err == nil {
+		t.Fatal("PurgeDue reports no error for an account whose heir is gone")
+	}
+
+	var purged models.User
+	if err := db.Unscoped().First(&purged, other.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !purged.DeletedAt.Valid {
+		t.Error("an account after the failing one was not purged")
+	}
+	var kept models.User
+	if err := db.First(&kept, stuck.ID).Error; err != nil {
+		t.Errorf("the failing account was changed: %v", err)
+	}
+}
+
+func TestPurgeDeletesTagLinks(t *testing.T) {
+	db := newTestDB(t)
+	user := scheduleDeletion(t, db, "tess", PostsActionDelete, nil)
+	tag := models.Tag{Name: "go"}
+	if err := db.Create(&tag).Error; err != nil {
+		t.Fatal(err)
+	}
+	var posts []models.Post
+	db.Find(&posts)
+	for _, post := range posts {
+		if err := db.Model(&post).Association("Tags").Append(&tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := NewAccountPurger(db, discardLogger()).Purge(user); err != nil {
+		t.Fatal(err)
+	}
+
+	// the legacy post keeps its tag; the deleted post leaves no link behind
+	var links int64
+	db.Table("post_tags").Count(&links)
+	var orphans int64
+	db.Table("post_tags").Where("post_id NOT IN (?)", db.Unscoped().Model(&models.Post{}).Select("id")).Count(&orphans)
+	if links != 1 || orphans != 0 {
+		t.Errorf("%d tag links left, %d of them orphans; want 1 and 0", links, orphans)
+	}
+}
+
+func TestPurgeRemovesComments(t *testing.T) {
+	db := newTestDB(t)
+	user := scheduleDeletion(t, db, "cleo", PostsActionDelete, nil)
+	other := models.User{Username: "otto", Email: "otto@example.com", Password: "x", IsActive: true}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	var linked, legacy models.Post
+	db.Where("title = ?", "linked").First(&linked)
+	db.Where("title = ?", "legacy").First(&legacy)
+
+	// cleo comments on the legacy post, otto on both; counts as the handlers keep them
+	comments := []models.Comment{
+		{PostID: legacy.ID, UserID: user.ID, Author: "cleo", Content: "mine"},
+		{PostID: legacy.ID, UserID: other.ID, Author: "otto", Content: "his"},
+		{PostID: linked.ID, UserID: other.ID, Author: "otto", Content: "on cleo's post"},
+	}
+	if err := db.Create(&comments).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("UPDATE posts SET comment_count = 2 WHERE id = ?", legacy.ID)
+	db.Exec("UPDATE posts SET comment_count = 1 WHERE id = ?", linked.ID)
+
+	if err := NewAccountPurger(db, discardLogger()).Purge(user); err != nil {
+		t.Fatal(err)
+	}
+
+	var left []models.Comment
+	db.Unscoped().Find(&left)
+	if len(left) != 1 || left[0].Content != "his" {
+		t.Errorf("comments left: %+v, want only otto's on the legacy post", left)
+	}
+	if err := db.First(&legacy, legacy.ID).Error; err != nil || legacy.CommentCount != 1 {
+		t.Errorf("legacy post comment_count = %d (%v), want 1", legacy.CommentCount, err)
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"gorm.io/gorm"
+
+	"blog-app-backend/models"
+)
+
+// AuditLog writes the admin audit trail
+type AuditLog struct {
+	db  *gorm.DB
+	log *slog.Logger
+}
+
+func NewAuditLog(db *gorm.DB, logger *slog.Logger) *AuditLog {
+	return &AuditLog{db: db, log: logger}
+}
+
+// Record writes an entry to the audit trail. Details are stored as JSON.
+// A failure is logged rather than returned, so auditing never blocks the
+// action itself.
+func (a *AuditLog) Record(actorID uint, action string, targetUserID *uint, ip string, details map[string]interface{}) {
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		encoded = []byte("{}")
+	}
+
+	entry := models.AuditLog{
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Details:      string(encoded),
+		IP:           ip,
+	}
+	if err := a.db.Create(&entry).Error; err != nil {
+		a.log.Error("failed to write audit log", "action", action, "actor_id", actorID, "error", err)
+	}
+}
@@ -2,14 +2,15 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"os"
 	"strings"
-	"time"
+
+	"blog-app-backend/config"
+	"blog-app-backend/tracing"
 )
 
 type DeepSeekRequest struct {
@@ -36,20 +37,29 @@ type APIError struct {
 	Type    string `json:"type"`
 }
 
+// Moderator decides whether a post may be published
+type Moderator interface {
+	CheckContent(ctx context.Context, title, content string) (bool, error)
+}
+
+// Pinger is implemented by dependencies that can report whether they are reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 type ContentFilterService struct {
 	apiKey  string
 	baseURL string
 	client  *http.Client
 }
 
-func NewContentFilterService() *ContentFilterService {
-	apiKey := os.Getenv("DEEPSEEK_API_KEY")
-
+func NewContentFilterService(cfg config.ModerationConfig) *ContentFilterService {
 	return &ContentFilterService{
-		apiKey:  apiKey,
-		baseURL: "https://api.deepseek.com/v1/chat/completions",
+		apiKey:  cfg.APIKey,
+		baseURL: cfg.BaseURL,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   cfg.Timeout,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -69,7 +79,33 @@ func max(a, b int) int {
 	return b
 }
 
-func (c *ContentFilterService) CheckContent(title, content string) (bool, error) {
+// Ping checks that the DeepSeek API is reachable and accepts our key by
+// listing the available models, which costs nothing
+func (c *ContentFilterService) Ping(ctx context.Context) error {
+	if c.apiKey == "" {
+		return fmt.Errorf("DEEPSEEK_API_KEY environment variable is not set")
+	}
+
+	url := strings.TrimSuffix(c.baseURL, "/chat/completions") + "/models"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *ContentFilterService) CheckContent(ctx context.Context, title, content string) (bool, error) {
 	if c.apiKey == "" {
 		return false, fmt.Errorf("DEEPSEEK_API_KEY environment variable is not set")
 	}
@@ -96,7 +132,7 @@ Respond with only "CLEAN" if the content is appropriate, or "INAPPROPRIATE" if i
 		return false, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return false, fmt.Errorf("failed to create request: %v", err)
 	}
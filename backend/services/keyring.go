@@ -0,0 +1,230 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"blog-app-backend/config"
+)
+
+// SigningKey is one private key of the ring. Its ID is sent as the "kid"
+// header of every token it signs.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	// RetiredAt is when the key stopped signing; zero for the active key
+	RetiredAt time.Time
+}
+
+// KeyRing signs tokens with the active key and verifies tokens signed with
+// any key that is still inside the rotation overlap.
+//
+// Keys are PEM files in jwt.keys_dir (JWT_KEYS_DIR), one per key, named
+// <kid>.pem. Both Ed25519 (EdDSA) and RSA (RS256) keys are supported:
+//
+//	openssl genpkey -algorithm ed25519 -out keys/2025-01.pem
+//	openssl genpkey -algorithm rsa -pkeyopt rsa_keygen_bits:2048 -out keys/2025-01.pem
+//
+// The active key is jwt.active_kid, or else the last kid in sort order, so
+// every replica picks the same key whatever the files' timestamps; name
+// keys so that they sort by age, e.g. by date. Every other key must be
+// listed in jwt.retired_keys with the time it stopped signing, and its
+// tokens stay valid until jwt.key_overlap (default 24h) after that. The
+// times come from the configuration, never from when a process started,
+// so restarts don't revive old keys and all replicas agree.
+//
+// To rotate, add the new key file, retire the previous key as of now and
+// restart; once the overlap has passed, the old file can be removed.
+type KeyRing struct {
+	active  *SigningKey
+	keys    map[string]*SigningKey
+	overlap time.Duration
+}
+
+// NewKeyRing loads the configured key ring
+func NewKeyRing(cfg config.JWTConfig) (*KeyRing, error) {
+	retired, err := cfg.Retirements()
+	if err != nil {
+		return nil, err
+	}
+	return LoadKeyRing(cfg.KeysDir, cfg.ActiveKID, retired, cfg.KeyOverlap)
+}
+
+// LoadKeyRing reads every *.pem key in dir; retired holds when each key
+// but the active one stopped signing
+func LoadKeyRing(dir, activeID string, retired map[string]time.Time, overlap time.Duration) (*KeyRing, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no JWT signing keys found in %s", dir)
+	}
+
+	ring := &KeyRing{keys: make(map[string]*SigningKey), overlap: overlap}
+	for _, file := range files {
+		key, err := loadSigningKey(file)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[key.ID] = key
+	}
+
+	if activeID != "" {
+		ring.active = ring.keys[activeID]
+		if ring.active == nil {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID %q not found in %s", activeID, dir)
+		}
+	} else {
+		for _, key := range ring.keys {
+			if ring.active == nil || key.ID > ring.active.ID {
+				ring.active = key
+			}
+		}
+	}
+
+	for id, key := range ring.keys {
+		at, ok := retired[id]
+		switch {
+		case key == ring.active && ok:
+			return nil, fmt.Errorf("JWT key %q is retired but is the active key", id)
+		case key != ring.active && !ok:
+			return nil, fmt.Errorf("JWT key %q is not active: list it in JWT_RETIRED_KEYS with the time it stopped signing", id)
+		}
+		key.RetiredAt = at
+	}
+
+	return ring, nil
+}
+
+func loadSigningKey(file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", file)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	key := &SigningKey{ID: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method, key.Private = jwt.SigningMethodEdDSA, k
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("%s: RSA keys must be at least 2048 bits", file)
+		}
+		key.Method, key.Private = jwt.SigningMethodRS256, k
+	default:
+		return nil, fmt.Errorf("%s: only Ed25519 and RSA keys are supported", file)
+	}
+
+	return key, nil
+}
+
+// verificationKeys returns the active key plus the retired keys still in
+// the overlap
+func (r *KeyRing) verificationKeys() []*SigningKey {
+	keys := []*SigningKey{r.active}
+	now := time.Now()
+	for _, key := range r.keys {
+		if key != r.active && now.Before(key.RetiredAt.Add(r.overlap)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys[1:], func(i, j int) bool { return keys[i+1].ID < keys[j+1].ID })
+	return keys
+}
+
+// Sign signs the claims with the active key
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.active.Method, claims)
+	token.Header["kid"] = r.active.ID
+	return token.SignedString(r.active.Private)
+}
+
+// Parse verifies the token's signature and expiry and returns its claims
+func (r *KeyRing) Parse(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		for _, key := range r.verificationKeys() {
+			if key.ID == kid {
+				// verify signing method matches the key
+				if t.Method.Alg() != key.Method.Alg() {
+					return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+				}
+				return key.Private.Public(), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS returns the public half of every key that can currently verify tokens
+func (r *KeyRing) JWKS() []JWK {
+	var jwks []JWK
+	for _, key := range r.verificationKeys() {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch pub := key.Private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType, jwk.Curve = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeKey(t *testing.T, dir, kid string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), block, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyRingRetirement(t *testing.T) {
+	dir := t.TempDir()
+	for _, kid := range []string{"2025-01", "2025-06", "2026-01"} {
+		writeKey(t, dir, kid)
+	}
+	now := time.Now()
+	retired := map[string]time.Time{
+		"2025-01": now.Add(-48 * time.Hour), // past the overlap
+		"2025-06": now.Add(-time.Hour),      // inside it
+	}
+
+	// the retired keys sign a token each, before they were retired
+	old, err := LoadKeyRing(dir, "2025-01", map[string]time.Time{"2025-06": now, "2026-01": now}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _ := old.Sign(jwt.MapClaims{"sub": "1"})
+	recent, err := LoadKeyRing(dir, "2025-06", map[string]time.Time{"2025-01": now, "2026-01": now}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recentToken, _ := recent.Sign(jwt.MapClaims{"sub": "1"})
+
+	// a restart loads the ring again: the overlap does not start over
+	for i := 0; i < 2; i++ {
+		ring, err := LoadKeyRing(dir, "", retired, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ring.Parse(oldToken); err == nil {
+			t.Errorf("load %d: a key retired before the overlap still verifies", i+1)
+		}
+		if _, err := ring.Parse(recentToken); err != nil {
+			t.Errorf("load %d: a key inside the overlap does not verify: %v", i+1, err)
+		}
+		var kids []string
+		for _, jwk := range ring.JWKS() {
+			kids = append(kids, jwk.KeyID)
+		}
+		if got := strings.Join(kids, ","); got != "2026-01,2025-06" {
+			t.Errorf("load %d: JWKS publishes %s, want 2026-01,2025-06", i+1, got)
+		}
+	}
+}
+
+func TestKeyRingNeedsRetirementTimes(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2025-01")
+	writeKey(t, dir, "2026-01")
+
+	if _, err := LoadKeyRing(dir, "", nil, time.Hour); err == nil {
+		t.Error("a key that is neither active nor retired was accepted")
+	}
+	if _, err := LoadKeyRing(dir, "", map[string]time.Time{"2025-01": time.Now(), "2026-01": time.Now()}, time.Hour); err == nil {
+		t.Error("the active key was accepted as retired")
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"blog-app-backend/config"
+)
+
+// Mailer sends plain-text notification emails to users.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer returns an SMTP mailer when an SMTP host is configured, otherwise
+// a mailer that only writes the message to the log (useful in development).
+func NewMailer(cfg config.MailConfig, logger *slog.Logger) Mailer {
+	if cfg.SMTPHost == "" {
+		return LogMailer{Logger: logger}
+	}
+
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:     cfg.SMTPHost,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.From,
+	}
+}
+
+// LogMailer logs emails instead of sending them. The body is only logged
+// at debug level, since it may hold reset links and session details.
+type LogMailer struct {
+	Logger *slog.Logger
+}
+
+func (m LogMailer) Send(to, subject, body string) error {
+	logger := m.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Info("mail not sent, no SMTP host configured", "to", to, "subject", subject)
+	logger.Debug("mail body", "to", to, "body", body)
+	return nil
+}
+
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
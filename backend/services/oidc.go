@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"blog-app-backend/config"
+)
+
+// OIDCProvider performs the authorization code flow against one provider.
+// The discovery document is fetched on first use, so the app can start
+// while the identity provider is unreachable.
+type OIDCProvider struct {
+	config config.OIDCProviderConfig
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// OIDCIdentity holds the verified claims of an ID token
+type OIDCIdentity struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+	Nonce             string `json:"nonce"`
+}
+
+// NewOIDCProviders creates a provider for each configured entry
+func NewOIDCProviders(cfg config.OIDCConfig) map[string]*OIDCProvider {
+	providers := make(map[string]*OIDCProvider)
+	for _, p := range cfg.Providers {
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+		providers[p.Name] = &OIDCProvider{config: p}
+	}
+	return providers
+}
+
+// Name returns the provider's configured name
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load discovery document for %s: %v", p.config.Name, err)
+	}
+	p.provider = provider
+	return provider, nil
+}
+
+func (p *OIDCProvider) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.config.Scopes,
+	}
+}
+
+// AuthCodeURL returns the provider's authorization URL for a PKCE flow
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return p.oauth2Config(provider).AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	), nil
+}
+
+// Exchange trades the authorization code for tokens and verifies the ID
+// token's signature (against the provider's JWKS), issuer, audience, expiry
+// and nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce, verifier string) (*OIDCIdentity, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %v", err)
+	}
+
+	var identity OIDCIdentity
+	if err := idToken.Claims(&identity); err != nil {
+		return nil, fmt.Errorf("failed to parse id_token claims: %v", err)
+	}
+
+	if identity.Nonce != nonce {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+
+	return &identity, nil
+}
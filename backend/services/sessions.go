@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"blog-app-backend/models"
+)
+
+// SessionLifetime is how long a login (and its JWT) stays valid
+const SessionLifetime = 24 * time.Hour
+
+// lastSeenInterval limits how often a session's LastSeenAt is written back
+const lastSeenInterval = time.Minute
+
+var (
+	ErrInvalidToken    = errors.New("invalid or expired token")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+	ErrAccountDisabled = errors.New("account is disabled")
+)
+
+// SessionService creates, authenticates and revokes login sessions. Each
+// session is bound to one JWT through the token's "jti" claim.
+type SessionService struct {
+	db     *gorm.DB
+	keys   *KeyRing
+	mailer Mailer
+	log    *slog.Logger
+}
+
+func NewSessionService(db *gorm.DB, keys *KeyRing, mailer Mailer, logger *slog.Logger) *SessionService {
+	return &SessionService{db: db, keys: keys, mailer: mailer, log: logger}
+}
+
+// NewSession describes the session to start
+type NewSession struct {
+	UserAgent      string
+	IP             string
+	Lifetime       time.Duration
+	ImpersonatorID *uint
+}
+
+// Start stores a new session for the user and returns it with its signed JWT.
+// A login from a user agent the user hasn't used before triggers an email.
+func (s *SessionService) Start(ctx context.Context, user models.User, opts NewSession) (models.Session, string, error) {
+	db := s.db.WithContext(ctx)
+	if opts.Lifetime == 0 {
+		opts.Lifetime = SessionLifetime
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:         user.ID,
+		TokenID:        uuid.NewString(),
+		UserAgent:      truncate(opts.UserAgent, 255),
+		IP:             opts.IP,
+		LastSeenAt:     now,
+		ExpiresAt:      now.Add(opts.Lifetime),
+		ImpersonatorID: opts.ImpersonatorID,
+	}
+
+	// a device is "known" if the user has logged in with the same user agent before
+	var previous, sameDevice int64
+	if opts.ImpersonatorID == nil {
+		db.Model(&models.Session{}).Where("user_id = ?", user.ID).Count(&previous)
+		db.Model(&models.Session{}).
+			Where("user_id = ? AND user_agent = ?", user.ID, session.UserAgent).
+			Count(&sameDevice)
+	}
+
+	if err := db.Create(&session).Error; err != nil {
+		return session, "", err
+	}
+
+	// generate JWT bound to the session, signed with the active key
+	claims := jwt.MapClaims{
+		"jti":      session.TokenID,
+		"user_id":  user.ID,
+		"username": user.Username,
+		"exp":      session.ExpiresAt.Unix(),
+	}
+	if session.ImpersonatorID != nil {
+		// RFC 8693 actor claim: who is really acting as this user
+		claims["act"] = jwt.MapClaims{"sub": *session.ImpersonatorID}
+	}
+	token, err := s.keys.Sign(claims)
+	if err != nil {
+		return session, "", err
+	}
+
+	if previous > 0 && sameDevice == 0 {
+		go s.notifyNewDevice(user, session)
+	}
+
+	return session, token, nil
+}
+
+// notifyNewDevice emails the user about a login from a device not seen before
+func (s *SessionService) notifyNewDevice(user models.User, session models.Session) {
+	body := fmt.Sprintf(`Hi %s,
+
+Your account was just signed in from a new device.
+
+Time:    %s
+IP:      %s
+Browser: %s
+
+If this was you, you can ignore this email. If not, revoke the session from your account settings and change your password.`,
+		user.Username, session.CreatedAt.Format(time.RFC1123), session.IP, session.UserAgent)
+
+	if err := s.mailer.Send(user.Email, "New login to your account", body); err != nil {
+		s.log.Error("failed to send new device email", "user_id", user.ID, "error", err)
+	}
+}
+
+// Authenticate verifies a JWT and returns its session and user. It fails for
+// revoked sessions and disabled accounts.
+func (s *SessionService) Authenticate(ctx context.Context, tokenStr string) (models.Session, models.User, error) {
+	db := s.db.WithContext(ctx)
+	var session models.Session
+	var user models.User
+
+	claims, err := s.keys.Parse(tokenStr)
+	if err != nil {
+		return session, user, ErrInvalidToken
+	}
+
+	// token must belong to a session that has not been revoked. Read from the
+	// primary: replicas may lag behind a login or a revocation.
+	primary := db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+	tokenID, _ := claims["jti"].(string)
+	if err := primary.Where("token_id = ?", tokenID).First(&session).Error; err != nil {
+		return session, user, ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		return session, user, ErrSessionRevoked
+	}
+
+	// disabled accounts lose access immediately
+	if err := primary.Select("id", "username", "role", "is_active").First(&user, session.UserID).Error; err != nil || !user.IsActive {
+		return session, user, ErrAccountDisabled
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) > lastSeenInterval {
+		db.Model(&session).UpdateColumn("last_seen_at", now)
+	}
+
+	return session, user, nil
+}
+
+// RevokeToken marks the session issued for the token as revoked. Invalid
+// tokens are ignored: there is nothing to revoke.
+func (s *SessionService) RevokeToken(tokenStr string) error {
+	claims, err := s.keys.Parse(tokenStr)
+	if err != nil {
+		return nil
+	}
+	tokenID, _ := claims["jti"].(string)
+
+	return s.db.Model(&models.Session{}).
+		Where("token_id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Revoke revokes one session
+func (s *SessionService) Revoke(sessionID uint) error {
+	return s.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAll revokes every active session of the user
+func (s *SessionService) RevokeAll(userID uint) error {
+	return s.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// truncate cuts s to at most n bytes of valid UTF-8, at a rune boundary:
+// Postgres rejects anything else in a text column
+func truncate(s string, n int) string {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{"short", "curl/8.0", 255, "curl/8.0"},
+		{"ascii", "abcdef", 4, "abcd"},
+		{"mid-rune", "ab" + "ไทย", 4, "ab"},
+		{"rune boundary", "ab" + "ไทย", 5, "abไ"},
+		{"invalid input", "ab\xffcd", 255, "abcd"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := truncate(tc.in, tc.n); got != tc.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+			}
+		})
+	}
+
+	long := strings.Repeat("é", 200)
+	if got := truncate(long, 255); len(got) > 255 || !utf8.ValidString(got) {
+		t.Errorf("truncate of a long user agent: %d bytes, valid UTF-8 %v", len(got), utf8.ValidString(got))
+	}
+}
@@ -0,0 +1,107 @@
+package sitemap
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blog-app-backend/models"
+)
+
+// MaxCachedFiles bounds the cache; it holds a few files per 50,000 posts
+const MaxCachedFiles = 256
+
+// Cache keeps generated sitemaps until a post is published, edited or
+// deleted, or for ttl at most. It is a gorm plugin so that no write can
+// forget to invalidate it; the ttl bounds how stale a file gets when posts
+// change on another instance or outside gorm.
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	version uint64
+	files   map[string]cachedFile
+}
+
+type cachedFile struct {
+	body    []byte
+	expires time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, files: map[string]cachedFile{}}
+}
+
+// Get returns the file stored under key, building it when there is none
+// or it has expired. A nil file, for a sitemap that does not exist, is not
+// stored, and when the cache is full expired files make room for the new
+// one, or else an arbitrary file does.
+func (c *Cache) Get(key string, build func() ([]byte, error)) ([]byte, error) {
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.files[key]
+	version := c.version
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.body, nil
+	}
+
+	file, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	// a post changed while building: serve this one, but do not keep it
+	if c.version == version && file != nil {
+		if len(c.files) >= MaxCachedFiles {
+			for old, f := range c.files {
+				if now.After(f.expires) {
+					delete(c.files, old)
+				}
+			}
+		}
+		if len(c.files) >= MaxCachedFiles {
+			for old := range c.files {
+				delete(c.files, old)
+				break
+			}
+		}
+		c.files[key] = cachedFile{body: file, expires: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return file, nil
+}
+
+// Invalidate drops every stored file
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.version++
+	clear(c.files)
+	c.mu.Unlock()
+}
+
+func (c *Cache) Name() string { return "sitemap" }
+
+// Initialize registers the callbacks that invalidate the cache
+func (c *Cache) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	const after = "gorm:commit_or_rollback_transaction"
+	if err := cb.Create().After(after).Register("sitemap:after_create", c.postChanged); err != nil {
+		return err
+	}
+	if err := cb.Update().After(after).Register("sitemap:after_update", c.postChanged); err != nil {
+		return err
+	}
+	return cb.Delete().After(after).Register("sitemap:after_delete", c.postChanged)
+}
+
+var postType = reflect.TypeOf(models.Post{})
+
+func (c *Cache) postChanged(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.ModelType != postType {
+		return
+	}
+	c.Invalidate()
+}
@@ -0,0 +1,67 @@
+package sitemap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCacheIsBounded(t *testing.T) {
+	c := NewCache(time.Hour)
+	builds := 0
+	build := func() ([]byte, error) {
+		builds++
+		return []byte("file"), nil
+	}
+	for i := 0; i < 3*MaxCachedFiles; i++ {
+		if _, err := c.Get(fmt.Sprintf("posts-%d.xml", i), build); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.files) > MaxCachedFiles {
+		t.Errorf("%d files cached, want at most %d", len(c.files), MaxCachedFiles)
+	}
+
+	// the newest file is kept
+	builds = 0
+	c.Get(fmt.Sprintf("posts-%d.xml", 3*MaxCachedFiles-1), build)
+	if builds != 0 {
+		t.Error("the file just built was evicted")
+	}
+}
+
+func TestCacheSkipsMissingFiles(t *testing.T) {
+	c := NewCache(time.Hour)
+	file, err := c.Get("posts-99.xml", func() ([]byte, error) { return nil, nil })
+	if err != nil || file != nil {
+		t.Fatalf("Get = %q, %v; want nothing", file, err)
+	}
+	if len(c.files) != 0 {
+		t.Errorf("a sitemap that does not exist was cached")
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	c := NewCache(time.Hour)
+	builds := 0
+	build := func() ([]byte, error) {
+		builds++
+		return []byte("file"), nil
+	}
+	c.Get("pages-1.xml", build)
+	c.Get("pages-1.xml", build)
+	if builds != 1 {
+		t.Fatalf("%d builds within the ttl, want 1", builds)
+	}
+
+	// as if the ttl had passed
+	c.mu.Lock()
+	f := c.files["pages-1.xml"]
+	f.expires = time.Now().Add(-time.Second)
+	c.files["pages-1.xml"] = f
+	c.mu.Unlock()
+	c.Get("pages-1.xml", build)
+	if builds != 2 {
+		t.Errorf("an expired file was served from the cache")
+	}
+}
@@ -0,0 +1,68 @@
+// Package sitemap writes sitemaps (https://www.sitemaps.org/protocol.html)
+// and keeps the generated files until a post changes.
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs is the most URLs one sitemap file may list
+const MaxURLs = 50000
+
+// ContentType is served for sitemaps and sitemap indexes
+const ContentType = "application/xml; charset=utf-8"
+
+const ns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is one page, or one sitemap of an index
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type xmlURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+type index struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	NS       string   `xml:"xmlns,attr"`
+	Sitemaps []xmlURL `xml:"sitemap"`
+}
+
+// URLSet renders a sitemap of at most MaxURLs pages
+func URLSet(urls []URL) ([]byte, error) {
+	return marshal(urlSet{NS: ns, URLs: xmlURLs(urls)})
+}
+
+// Index renders a sitemap index of other sitemaps
+func Index(sitemaps []URL) ([]byte, error) {
+	return marshal(index{NS: ns, Sitemaps: xmlURLs(sitemaps)})
+}
+
+func xmlURLs(urls []URL) []xmlURL {
+	out := make([]xmlURL, len(urls))
+	for i, u := range urls {
+		out[i].Loc = u.Loc
+		if !u.LastMod.IsZero() {
+			out[i].LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+	}
+	return out
+}
+
+func marshal(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
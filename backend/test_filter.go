@@ -1,12 +1,16 @@
+//go:build ignore
+
 package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"blog-app-backend/config"
 	"blog-app-backend/services"
 )
 
@@ -66,7 +70,9 @@ func main() {
 		log.Fatal("Please set DEEPSEEK_API_KEY environment variable")
 	}
 
-	contentFilter := services.NewContentFilterService()
+	moderation := config.Defaults().Moderation
+	moderation.APIKey = os.Getenv("DEEPSEEK_API_KEY")
+	contentFilter := services.NewContentFilterService(moderation)
 
 	fmt.Println("🧪 Testing Content Filter with DeepSeek AI")
 	fmt.Println("==========================================")
@@ -75,7 +81,7 @@ func main() {
 		fmt.Printf("\n📝 Test %d: %s\n", i+1, test.title)
 		fmt.Printf("Content: %s\n", test.content)
 
-		isClean, err := contentFilter.CheckContent(test.title, test.content)
+		isClean, err := contentFilter.CheckContent(context.Background(), test.title, test.content)
 		if err != nil {
 			fmt.Printf("❌ Error: %v\n", err)
 			continue
@@ -96,4 +102,4 @@ func main() {
 	}
 
 	fmt.Println("\n🔄 Testing completed!")
-}
\ No newline at end of file
+}
@@ -0,0 +1,107 @@
+package theme
+
+import (
+	"encoding/json"
+	"html/template"
+	"time"
+)
+
+// Data is what every page is rendered with. Fields that do not apply to a
+// page are left empty.
+type Data struct {
+	Site Site
+	// Title is the document <title>
+	Title string
+	Meta  Meta
+	// Feeds are offered as alternate links in <head>
+	Feeds []Link
+	// Prev and Next are the neighbouring pages of a paged list
+	Prev, Next  string
+	Page, Pages int
+
+	// Post is set on the post page
+	Post *Post
+	// Posts are listed on the home, tag and author pages
+	Posts  []Post
+	Tag    string
+	Author string
+	// Months list every post on the archive page
+	Months []Month
+}
+
+type Site struct {
+	Title       string
+	Description string
+	// URL has no trailing slash
+	URL string
+}
+
+// Meta feeds the canonical link and the OpenGraph, Twitter card and
+// JSON-LD data of a page
+type Meta struct {
+	// Type is the OpenGraph type: website or article
+	Type        string
+	Title       string
+	Description string
+	// URL is the canonical URL
+	URL       string
+	Published time.Time
+	Modified  time.Time
+	Author    string
+	Tags      []string
+	// JSONLD is a JSON-LD document for a <script type="application/ld+json">
+	JSONLD template.JS
+}
+
+type Post struct {
+	ID        uint
+	URL       string
+	Title     string
+	Author    string
+	AuthorURL string
+	Summary   string
+	Content   template.HTML
+	Tags      []Link
+	Published time.Time
+	Modified  time.Time
+}
+
+type Link struct {
+	Title string
+	URL   string
+	// Type is the media type of an alternate link
+	Type string
+}
+
+type Month struct {
+	// Label reads like "January 2026"
+	Label string
+	Posts []Post
+}
+
+// ArticleJSONLD describes post as a schema.org BlogPosting
+func ArticleJSONLD(site Site, post Post) template.JS {
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = tag.Title
+	}
+	doc := map[string]any{
+		"@context":         "https://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         post.Title,
+		"description":      post.Summary,
+		"url":              post.URL,
+		"mainEntityOfPage": post.URL,
+		"datePublished":    post.Published.UTC().Format(time.RFC3339),
+		"dateModified":     post.Modified.UTC().Format(time.RFC3339),
+		"author":           map[string]any{"@type": "Person", "name": post.Author, "url": post.AuthorURL},
+		"publisher":        map[string]any{"@type": "Organization", "name": site.Title, "url": site.URL + "/"},
+	}
+	if len(tags) > 0 {
+		doc["keywords"] = tags
+	}
+	// json.Marshal escapes <, > and &, so the result cannot close the
+	// <script> element it is placed in
+	out, _ := json.Marshal(doc)
+	return template.JS(out)
+}
@@ -0,0 +1,150 @@
+// Package theme renders the public pages with html/template themes. A
+// theme is a directory of templates: one per page, plus shared files whose
+// names start with "_", such as _layout.html. Any file a theme leaves out
+// is taken from the built-in default theme, so a theme can be as small as
+// one stylesheet partial.
+package theme
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pages every theme can render
+const (
+	PageHome     = "home"
+	PagePost     = "post"
+	PageTag      = "tag"
+	PageAuthor   = "author"
+	PageArchive  = "archive"
+	PageNotFound = "notfound"
+)
+
+var pages = []string{PageHome, PagePost, PageTag, PageAuthor, PageArchive, PageNotFound}
+
+// DefaultName is the built-in theme
+const DefaultName = "default"
+
+//go:embed default/*.html
+var defaultFiles embed.FS
+
+// Theme is a parsed theme, ready to render any page
+type Theme struct {
+	Name string
+	// Version is a digest of the templates, which changes whenever any of
+	// them does
+	Version string
+	pages   map[string]*template.Template
+}
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("January 2, 2006") },
+	"iso":  func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}
+
+// Load parses the theme called name from dir, or the default theme
+func Load(dir, name string) (*Theme, error) {
+	base, err := fs.Sub(defaultFiles, DefaultName)
+	if err != nil {
+		return nil, err
+	}
+	var custom fs.FS
+	if name != DefaultName {
+		if dir == "" {
+			return nil, fmt.Errorf("theme %q: no themes directory configured", name)
+		}
+		if strings.ContainsAny(name, `/\`) || name == ".." {
+			return nil, fmt.Errorf("theme %q: not a directory name", name)
+		}
+		root := filepath.Join(dir, name)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("theme %q: %s is not a directory", name, root)
+		}
+		custom = os.DirFS(root)
+	}
+
+	// shared files, the theme's version of each winning
+	shared := map[string]fs.FS{}
+	for _, fsys := range []fs.FS{base, custom} {
+		if fsys == nil {
+			continue
+		}
+		names, err := fs.Glob(fsys, "_*.html")
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			shared[n] = fsys
+		}
+	}
+
+	sharedNames := make([]string, 0, len(shared))
+	for n := range shared {
+		sharedNames = append(sharedNames, n)
+	}
+	sort.Strings(sharedNames)
+
+	t := &Theme{Name: name, pages: map[string]*template.Template{}}
+	version := sha256.New()
+	for i, page := range pages {
+		tmpl := template.New(page).Funcs(funcs)
+		for _, n := range sharedNames {
+			src, err := parseFile(tmpl, shared[n], n)
+			if err != nil {
+				return nil, fmt.Errorf("theme %q: %w", name, err)
+			}
+			if i == 0 {
+				fmt.Fprintf(version, "%s\x00%d\x00%s", n, len(src), src)
+			}
+		}
+		file, fsys := page+".html", base
+		if custom != nil {
+			if _, err := fs.Stat(custom, file); err == nil {
+				fsys = custom
+			}
+		}
+		src, err := parseFile(tmpl, fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("theme %q: %w", name, err)
+		}
+		fmt.Fprintf(version, "%s\x00%d\x00%s", file, len(src), src)
+		if tmpl.Lookup("layout") == nil {
+			return nil, fmt.Errorf("theme %q: %s does not define a layout", name, file)
+		}
+		t.pages[page] = tmpl
+	}
+	t.Version = hex.EncodeToString(version.Sum(nil)[:8])
+	return t, nil
+}
+
+// parseFile adds the template in name to tmpl and returns its source
+func parseFile(tmpl *template.Template, fsys fs.FS, name string) ([]byte, error) {
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.New(path.Base(name)).Parse(string(src)); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// Render writes page with data; data is usually a *Data
+func (t *Theme) Render(w io.Writer, page string, data any) error {
+	tmpl, ok := t.pages[page]
+	if !ok {
+		return fmt.Errorf("theme %q has no page %q", t.Name, page)
+	}
+	return tmpl.ExecuteTemplate(w, "layout", data)
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin records a client span for every gorm operation. Queries join
+// the request's trace when they run on db.WithContext(c.UserContext()).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string { return "tracing" }
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(string, func(*gorm.DB)) error
+		after     func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"select", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, h := range hooks {
+		if err := h.before("tracing:before_"+h.operation, startSpan(h.operation)); err != nil {
+			return err
+		}
+		if err := h.after("tracing:after_"+h.operation, endSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gormSpan is kept on the statement between the before and after callbacks
+type gormSpan struct {
+	span      trace.Span
+	operation string
+}
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		_, span := tracer().Start(db.Statement.Context, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBOperationName(operation),
+				dbSystem(db.Dialector.Name()),
+			),
+		)
+		db.InstanceSet(gormSpanKey, gormSpan{span: span, operation: operation})
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	s := value.(gormSpan)
+	defer s.span.End()
+
+	// the SQL keeps its placeholders, so no values end up in the trace
+	s.span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.response.affected_rows", db.Statement.RowsAffected),
+	)
+	// the table is only known once gorm has parsed the statement
+	if table := db.Statement.Table; table != "" {
+		s.span.SetName("db." + s.operation + " " + table)
+		s.span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// dbSystem maps a gorm dialector name to the semantic convention's
+func dbSystem(dialector string) attribute.KeyValue {
+	switch dialector {
+	case "mysql":
+		return semconv.DBSystemNameMySQL
+	case "postgres":
+		return semconv.DBSystemNamePostgreSQL
+	case "sqlite":
+		return semconv.DBSystemNameSQLite
+	}
+	return semconv.DBSystemNameKey.String(dialector)
+}
@@ -11,13 +11,16 @@ export default function PostsList({ initialData }) {
     Math.ceil(initialData.total / initialData.page_size) || 1
   );
   const [searchQuery, setSearchQuery] = useState("");
+  const [suggestions, setSuggestions] = useState([]);
+  const [didYouMean, setDidYouMean] = useState("");
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState("");
   const [showCreateForm, setShowCreateForm] = useState(false);
   const [createFormData, setCreateFormData] = useState({
     title: "",
     content: "",
-    author: ""
+    author: "",
+    tags: ""
   });
   const [createLoading, setCreateLoading] = useState(false);
   const router = useRouter();
@@ -68,11 +71,44 @@ export default function PostsList({ initialData }) {
     }
   }, []);
 
+  useEffect(() => {
+    // Suggest completions while typing, once the user pauses
+    const prefix = searchQuery.trim();
+    if (!prefix) {
+      setSuggestions([]);
+      setDidYouMean("");
+      return;
+    }
+
+    const timer = setTimeout(async () => {
+      try {
+        const response = await authenticatedFetch(
+          `${process.env.NEXT_PUBLIC_API_BASE_URL}/search/suggest?${new URLSearchParams({ prefix })}`
+        );
+        if (!response.ok) return;
+        const data = await response.json();
+        setSuggestions(data.items || []);
+        setDidYouMean(data.did_you_mean || "");
+      } catch {
+        setSuggestions([]);
+      }
+    }, 200);
+    return () => clearTimeout(timer);
+  }, [searchQuery]);
+
   const handleSearch = (e) => {
     e.preventDefault();
+    setSuggestions([]);
     fetchPosts(1, searchQuery);
   };
 
+  const chooseSuggestion = (text) => {
+    setSearchQuery(text);
+    setSuggestions([]);
+    setDidYouMean("");
+    fetchPosts(1, text);
+  };
+
   const handleLogout = async () => {
     await logout();
   };
@@ -96,7 +132,10 @@ export default function PostsList({ initialData }) {
           headers: {
             "Content-Type": "application/json",
           },
-          body: JSON.stringify(createFormData),
+          body: JSON.stringify({
+            ...createFormData,
+            tags: createFormData.tags.split(",").map((t) => t.trim()).filter(Boolean),
+          }),
         }
       );
 
@@ -109,7 +148,7 @@ export default function PostsList({ initialData }) {
       alert("🎉 Post created successfully!");
 
       // Reset form and close
-      setCreateFormData({ title: "", content: "", author: "" });
+      setCreateFormData({ title: "", content: "", author: "", tags: "" });
       setShowCreateForm(false);
 
       // Refresh posts list
@@ -169,7 +208,7 @@ export default function PostsList({ initialData }) {
                   <button
                     onClick={() => {
                       setShowCreateForm(false);
-                      setCreateFormData({ title: "", content: "", author: "" });
+                      setCreateFormData({ title: "", content: "", author: "", tags: "" });
                       setError("");
                     }}
                     className="text-gray-400 hover:text-gray-600"
@@ -226,6 +265,20 @@ export default function PostsList({ initialData }) {
                     />
                   </div>
 
+                  <div>
+                    <label htmlFor="tags" className="block text-sm font-medium text-gray-700 mb-1">
+                      Tags
+                    </label>
+                    <input
+                      type="text"
+                      id="tags"
+                      value={createFormData.tags}
+                      onChange={(e) => setCreateFormData({ ...createFormData, tags: e.target.value })}
+                      className="w-full px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500"
+                      placeholder="go, web-dev (optional, comma separated)"
+                    />
+                  </div>
+
                   <div className="flex gap-3 pt-4">
                     <button
                       type="submit"
@@ -238,7 +291,7 @@ export default function PostsList({ initialData }) {
                       type="button"
                       onClick={() => {
                         setShowCreateForm(false);
-                        setCreateFormData({ title: "", content: "", author: "" });
+                        setCreateFormData({ title: "", content: "", author: "", tags: "" });
                         setError("");
                       }}
                       className="flex-1 bg-gray-300 hover:bg-gray-400 text-gray-700 font-medium py-2 px-4 rounded-md transition"
@@ -254,13 +307,42 @@ export default function PostsList({ initialData }) {
 
         {/* Search */}
         <form onSubmit={handleSearch} className="flex gap-4 mb-6">
-          <input
-            type="text"
-            placeholder="Search posts..."
-            value={searchQuery}
-            onChange={(e) => setSearchQuery(e.target.value)}
-            className="flex-1 px-4 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500"
-          />
+          <div className="relative flex-1">
+            <input
+              type="text"
+              placeholder="Search posts..."
+              value={searchQuery}
+              onChange={(e) => setSearchQuery(e.target.value)}
+              className="w-full px-4 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500"
+            />
+            {(suggestions.length > 0 || didYouMean) && (
+              <ul className="absolute z-10 mt-1 w-full bg-white border border-gray-200 rounded-md shadow">
+                {didYouMean && (
+                  <li>
+                    <button
+                      type="button"
+                      onClick={() => chooseSuggestion(didYouMean)}
+                      className="w-full text-left px-4 py-2 text-sm text-gray-600 hover:bg-gray-100"
+                    >
+                      Did you mean <span className="font-medium text-blue-600">{didYouMean}</span>?
+                    </button>
+                  </li>
+                )}
+                {suggestions.map((s) => (
+                  <li key={`${s.kind}:${s.text}`}>
+                    <button
+                      type="button"
+                      onClick={() => chooseSuggestion(s.text)}
+                      className="w-full flex justify-between px-4 py-2 text-left hover:bg-gray-100"
+                    >
+                      <span>{s.text}</span>
+                      <span className="text-xs text-gray-400">{s.kind}</span>
+                    </button>
+                  </li>
+                ))}
+              </ul>
+            )}
+          </div>
           <button
             type="submit"
             disabled={loading}
@@ -291,6 +373,15 @@ export default function PostsList({ initialData }) {
               >
                 <h2 className="text-xl font-semibold mb-2">{post.title}</h2>
                 <p className="text-gray-600 mb-3 line-clamp-3">{post.content}</p>
+                {post.tags?.length > 0 && (
+                  <div className="flex flex-wrap gap-2 mb-3">
+                    {post.tags.map((tag) => (
+                      <span key={tag.name} className="text-xs bg-blue-50 text-blue-700 px-2 py-1 rounded">
+                        #{tag.name}
+                      </span>
+                    ))}
+                  </div>
+                )}
                 <div className="text-sm text-gray-500 flex justify-between">
                   <span>By {post.author || "Anonymous"}</span>
                   <time>{formatDate(post.created_at)}</time>